@@ -0,0 +1,320 @@
+// Command ble-scan lists nearby BLE advertisers with their address, local name,
+// RSSI, advertised services and manufacturer data, and optionally connects to
+// selected devices to dump their GATT services and characteristics.
+//
+// Usage:
+//
+//	ble-scan [-adapter hci1] [-duration 10s] [-filter text] [-connect name-or-address,...] [-v]
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/columbus"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/timeular"
+	"tinygo.org/x/bluetooth"
+)
+
+// knownServices labels the services this toolkit knows about
+var knownServices = map[bluetooth.UUID]string{
+	columbus.ServiceUUID:                   "Columbus",
+	timeular.ServiceUUID:                   "Timeular",
+	bluetooth.ServiceUUIDNordicUART:        "Nordic UART",
+	bluetooth.ServiceUUIDBattery:           "Battery",
+	bluetooth.ServiceUUIDDeviceInformation: "Device Information",
+	bluetooth.ServiceUUIDGenericAccess:     "Generic Access",
+	bluetooth.ServiceUUIDGenericAttribute:  "Generic Attribute",
+}
+
+// advertiser aggregates the advertisements of one device seen during the scan
+type advertiser struct {
+	address          bluetooth.Address
+	localName        string
+	rssi             int16
+	services         []bluetooth.UUID
+	manufacturerData []bluetooth.ManufacturerDataElement
+	count            int
+}
+
+func main() {
+	duration := flag.Duration("duration", 10*time.Second, "how long to scan")
+	filter := flag.String("filter", "", "only list devices whose name or address contains this text")
+	connect := flag.String("connect", "", "comma-separated names or addresses of devices to connect to and inspect")
+	verbose := flag.Bool("v", false, "log adapter diagnostics to stderr")
+	adapterID := flag.String("adapter", "", "BlueZ adapter to use, e.g. hci1 (Linux only, default adapter if empty)")
+	flag.Parse()
+
+	adapter := ble.NewTinyGoAdapter(bluetooth.DefaultAdapter)
+	if *adapterID != "" {
+		var err error
+		if adapter, err = ble.NewAdapterByID(*adapterID); err != nil {
+			log.Fatalf("failed to select adapter: %v", err)
+		}
+	}
+	if logged, ok := adapter.(interface{ SetLogger(*slog.Logger) }); ok && *verbose {
+		logged.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+
+	if err := adapter.Enable(); err != nil {
+		log.Fatalf("failed to enable adapter: %v", err)
+	}
+
+	advertisers, err := scan(adapter, *duration, os.Stderr)
+	if err != nil {
+		log.Fatalf("scan failed: %v", err)
+	}
+
+	printAdvertisers(os.Stdout, advertisers, *filter)
+
+	if *connect == "" {
+		return
+	}
+	for _, target := range strings.Split(*connect, ",") {
+		target = strings.TrimSpace(target)
+		found := false
+		for _, adv := range advertisers {
+			if strings.EqualFold(adv.address.String(), target) || adv.localName == target {
+				found = true
+				if err := inspect(os.Stdout, adapter, adv); err != nil {
+					fmt.Printf("  ✗ %v\n\n", err)
+				}
+			}
+		}
+		if !found {
+			fmt.Printf("%s was not seen during the scan\n\n", target)
+		}
+	}
+}
+
+// scan collects advertisements until duration has elapsed or the user interrupts
+func scan(adapter ble.Adapter, duration time.Duration, status io.Writer) ([]*advertiser, error) {
+	var (
+		mu          sync.Mutex
+		advertisers = make(map[string]*advertiser)
+	)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	stop := time.AfterFunc(duration, func() { adapter.StopScan() })
+	defer stop.Stop()
+	go func() {
+		if _, ok := <-interrupt; ok {
+			adapter.StopScan()
+		}
+	}()
+
+	fmt.Fprintf(status, "Scanning for %v (Ctrl+C to stop early)...\n", duration)
+	err := adapter.Scan(func(result bluetooth.ScanResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := result.Address.String()
+		adv, ok := advertisers[key]
+		if !ok {
+			adv = &advertiser{address: result.Address}
+			advertisers[key] = adv
+		}
+		adv.count++
+		adv.rssi = result.RSSI
+		if name := result.LocalName(); name != "" {
+			adv.localName = name
+		}
+		if services := advertisedServices(result); len(services) > 0 {
+			adv.services = services
+		}
+		if data := result.ManufacturerData(); len(data) > 0 {
+			// The payload may be reused by the stack once the callback returns
+			adv.manufacturerData = make([]bluetooth.ManufacturerDataElement, len(data))
+			for i, element := range data {
+				adv.manufacturerData[i] = bluetooth.ManufacturerDataElement{
+					CompanyID: element.CompanyID,
+					Data:      append([]byte(nil), element.Data...),
+				}
+			}
+		}
+	})
+	signal.Stop(interrupt)
+	close(interrupt)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// BlueZ keeps the service UUIDs of the parsed advertisements, including
+	// the ones advertisedServices cannot see
+	uuids, err := deviceServiceUUIDs()
+	if err != nil {
+		fmt.Fprintf(status, "advertised services incomplete: %v\n", err)
+	}
+	for key, adv := range advertisers {
+		adv.services = mergeUUIDs(adv.services, uuids[strings.ToUpper(key)])
+	}
+
+	result := make([]*advertiser, 0, len(advertisers))
+	for _, adv := range advertisers {
+		result = append(result, adv)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].rssi > result[j].rssi
+	})
+	return result, nil
+}
+
+// advertisedServices returns the service UUIDs of an advertisement, parsed
+// from the raw packet where the stack provides one. BlueZ only hands over the
+// parsed payload, which tinygo exposes through HasServiceUUID alone, so there
+// the known services are reported here and scan adds the others from
+// deviceServiceUUIDs.
+func advertisedServices(result bluetooth.ScanResult) []bluetooth.UUID {
+	if result.AdvertisementPayload == nil {
+		return nil
+	}
+	if uuids := parseServiceUUIDs(result.Bytes()); len(uuids) > 0 {
+		return uuids
+	}
+
+	var uuids []bluetooth.UUID
+	for uuid := range knownServices {
+		if result.HasServiceUUID(uuid) {
+			uuids = append(uuids, uuid)
+		}
+	}
+	return mergeUUIDs(nil, uuids)
+}
+
+// mergeUUIDs returns the UUIDs of both lists without duplicates, sorted
+func mergeUUIDs(a, b []bluetooth.UUID) []bluetooth.UUID {
+	var merged []bluetooth.UUID
+	seen := make(map[bluetooth.UUID]bool)
+	for _, uuid := range append(append([]bluetooth.UUID(nil), a...), b...) {
+		if !seen[uuid] {
+			seen[uuid] = true
+			merged = append(merged, uuid)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].String() < merged[j].String()
+	})
+	return merged
+}
+
+// parseServiceUUIDs extracts the service UUID lists from a raw advertising packet
+func parseServiceUUIDs(packet []byte) []bluetooth.UUID {
+	var uuids []bluetooth.UUID
+	for len(packet) > 1 {
+		length := int(packet[0])
+		if length == 0 || length >= len(packet) {
+			break
+		}
+		fieldType, data := packet[1], packet[2:length+1]
+		switch fieldType {
+		case 0x02, 0x03: // incomplete/complete list of 16-bit UUIDs
+			for i := 0; i+2 <= len(data); i += 2 {
+				uuids = append(uuids, bluetooth.New16BitUUID(uint16(data[i])|uint16(data[i+1])<<8))
+			}
+		case 0x06, 0x07: // incomplete/complete list of 128-bit UUIDs
+			for i := 0; i+16 <= len(data); i += 16 {
+				var b [16]byte
+				for j := range b {
+					b[j] = data[i+15-j] // little endian on air
+				}
+				uuids = append(uuids, bluetooth.NewUUID(b))
+			}
+		}
+		packet = packet[length+1:]
+	}
+	return uuids
+}
+
+// printAdvertisers prints every advertiser matching filter, strongest signal first
+func printAdvertisers(w io.Writer, advertisers []*advertiser, filter string) {
+	filter = strings.ToLower(filter)
+	shown := 0
+
+	for _, adv := range advertisers {
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(adv.localName), filter) &&
+			!strings.Contains(strings.ToLower(adv.address.String()), filter) {
+			continue
+		}
+		shown++
+
+		name := adv.localName
+		if name == "" {
+			name = "(no name)"
+		}
+		fmt.Fprintf(w, "%s  %4d dBm  %q  (%d advertisements)\n", adv.address.String(), adv.rssi, name, adv.count)
+		for _, uuid := range adv.services {
+			fmt.Fprintf(w, "    service       %s%s\n", uuid.String(), serviceLabel(uuid))
+		}
+		for _, element := range adv.manufacturerData {
+			fmt.Fprintf(w, "    manufacturer  0x%04x %s\n", element.CompanyID, hex.EncodeToString(element.Data))
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d of %d devices shown\n\n", shown, len(advertisers))
+}
+
+// inspect connects to an advertiser and prints its services and characteristics
+func inspect(w io.Writer, adapter ble.Adapter, adv *advertiser) error {
+	fmt.Fprintf(w, "Connecting to %s (%s)...\n", adv.address.String(), adv.localName)
+	conn, err := adapter.Connect(adv.address, bluetooth.ConnectionParams{
+		ConnectionTimeout: bluetooth.NewDuration(10 * time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("connection failed: %v", err)
+	}
+	defer conn.Disconnect()
+
+	services, err := conn.DiscoverServices(nil)
+	if err != nil {
+		return fmt.Errorf("service discovery failed: %v", err)
+	}
+
+	properties, err := characteristicProperties(adv.address)
+	if err != nil {
+		fmt.Fprintf(w, "  (characteristic properties unavailable: %v)\n", err)
+	}
+
+	for _, service := range services {
+		fmt.Fprintf(w, "  service %s%s\n", service.UUID().String(), serviceLabel(service.UUID()))
+
+		characteristics, err := service.DiscoverCharacteristics(nil)
+		if err != nil {
+			fmt.Fprintf(w, "    ✗ characteristic discovery failed: %v\n", err)
+			continue
+		}
+		for _, characteristic := range characteristics {
+			line := "    characteristic " + characteristic.UUID().String()
+			if flags := properties[service.UUID().String()+"/"+characteristic.UUID().String()]; len(flags) > 0 {
+				line += "  [" + strings.Join(flags, ", ") + "]"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// serviceLabel returns a " (name)" suffix for known services
+func serviceLabel(uuid bluetooth.UUID) string {
+	if name, ok := knownServices[uuid]; ok {
+		return " (" + name + ")"
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package main
+
+import (
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"tinygo.org/x/bluetooth"
+)
+
+// characteristicProperties returns the BlueZ flags (read, write, notify, ...)
+// of every characteristic of a connected device, keyed by
+// "<service UUID>/<characteristic UUID>". tinygo does not expose them on Linux.
+func characteristicProperties(address bluetooth.Address) (map[string][]string, error) {
+	objects, err := bluezObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	// Object paths look like /org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF/service000a/char000b
+	devicePath := "/dev_" + strings.ReplaceAll(address.MAC.String(), ":", "_") + "/"
+
+	services := make(map[dbus.ObjectPath]string)
+	for path, interfaces := range objects {
+		if service, ok := interfaces["org.bluez.GattService1"]; ok && strings.Contains(string(path), devicePath) {
+			uuid, _ := service["UUID"].Value().(string)
+			services[path] = uuid
+		}
+	}
+
+	properties := make(map[string][]string)
+	for path, interfaces := range objects {
+		characteristic, ok := interfaces["org.bluez.GattCharacteristic1"]
+		if !ok || !strings.Contains(string(path), devicePath) {
+			continue
+		}
+		servicePath, _ := characteristic["Service"].Value().(dbus.ObjectPath)
+		uuid, _ := characteristic["UUID"].Value().(string)
+		flags, _ := characteristic["Flags"].Value().([]string)
+		properties[strings.ToLower(services[servicePath]+"/"+uuid)] = flags
+	}
+	return properties, nil
+}
+
+// deviceServiceUUIDs returns the service UUIDs BlueZ has collected for every
+// device it knows, keyed by address. Unlike HasServiceUUID, this includes
+// vendor UUIDs this tool does not know about.
+func deviceServiceUUIDs() (map[string][]bluetooth.UUID, error) {
+	objects, err := bluezObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]bluetooth.UUID)
+	for _, interfaces := range objects {
+		device, ok := interfaces["org.bluez.Device1"]
+		if !ok {
+			continue
+		}
+		address, _ := device["Address"].Value().(string)
+		texts, _ := device["UUIDs"].Value().([]string)
+		var uuids []bluetooth.UUID
+		for _, text := range texts {
+			if uuid, err := bluetooth.ParseUUID(text); err == nil {
+				uuids = append(uuids, uuid)
+			}
+		}
+		// A device seen by several adapters appears once per adapter
+		key := strings.ToUpper(address)
+		result[key] = mergeUUIDs(result[key], uuids)
+	}
+	return result, nil
+}
+
+// bluezObjects returns all objects BlueZ exports with their interfaces and properties
+func bluezObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	err = conn.Object("org.bluez", "/").Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&objects)
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// characteristicProperties is only implemented on Linux, where BlueZ reports
+// the characteristic flags over D-Bus
+func characteristicProperties(address bluetooth.Address) (map[string][]string, error) {
+	return nil, errors.New("not supported on this platform")
+}
+
+// deviceServiceUUIDs has nothing to add on platforms where the stack hands
+// over the raw advertising packets
+func deviceServiceUUIDs() (map[string][]bluetooth.UUID, error) {
+	return nil, nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -23,6 +24,7 @@ func main() {
 
 	// Create a BLE manager
 	manager := ble.NewManager()
+	manager.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -71,20 +73,10 @@ func main() {
 		fmt.Println("🔄 Will attempt to reconnect...")
 	})
 
-	// Configure device for BLE manager
-	deviceConfig := ble.DeviceConfig{
-		Name:               columbusDevice.GetName(),
-		ServiceUUID:        columbusDevice.GetServiceUUID(),
-		CharacteristicUUID: columbusDevice.GetCharacteristicUUID(),
-		NotificationHandler: func(deviceName string, data []byte) error {
-			return columbusDevice.ProcessNotification(deviceName, data)
-		},
-	}
-
 	// Start connecting to devices
 	fmt.Println("🔍 Searching for Columbus Video Pen...")
 	fmt.Println("📱 Make sure your Columbus Video Pen is turned on and nearby!")
-	if err := manager.ConnectDevices([]ble.DeviceConfig{deviceConfig}); err != nil {
+	if err := manager.Register(columbusDevice); err != nil {
 		log.Fatalf("❌ Failed to connect to device: %v", err)
 	}
 
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -31,11 +32,12 @@ func main() {
 	timeularDevice2 := timeular.NewDeviceWithName("Timeular Tracker 2")
 
 	// You can also use different polling intervals for each device
-	timeularDevice1.SetPollInterval(500 * timeular.DefaultPollInterval)  // 500ms polling
-	timeularDevice2.SetPollInterval(1000 * timeular.DefaultPollInterval) // 1s polling
+	timeularDevice1.SetPollInterval(500 * time.Millisecond) // 500ms polling
+	timeularDevice2.SetPollInterval(time.Second)            // 1s polling
 
 	// Create a BLE manager
 	manager := ble.NewManager()
+	manager.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
 	// Track device states
 	var (
@@ -107,52 +109,16 @@ func main() {
 		return nil
 	})
 
-	// Set up disconnect handler
+	// Set up disconnect handler. The Timeular devices reset their own state.
 	manager.SetDisconnectHandler(func(deviceName, address string, err error) {
 		fmt.Printf("⚠️  Device %s [%s] disconnected: %v\n", deviceName, address, err)
 		fmt.Println("🔄 Will attempt to reconnect...")
-
-		// Reset device state on disconnect
-		switch deviceName {
-		case "Timeular Tracker 1":
-			timeularDevice1.Reset()
-		case "Timeular Tracker 2":
-			timeularDevice2.Reset()
-		}
 	})
 
-	// Configure all devices for BLE manager
-	deviceConfigs := []ble.DeviceConfig{
-		{
-			Name:               columbusDevice.GetName(),
-			ServiceUUID:        columbusDevice.GetServiceUUID(),
-			CharacteristicUUID: columbusDevice.GetCharacteristicUUID(),
-			NotificationHandler: func(deviceName string, data []byte) error {
-				return columbusDevice.ProcessNotification(deviceName, data)
-			},
-		},
-		{
-			Name:               timeularDevice1.GetName(),
-			ServiceUUID:        timeularDevice1.GetServiceUUID(),
-			CharacteristicUUID: timeularDevice1.GetCharacteristicUUID(),
-			NotificationHandler: func(deviceName string, data []byte) error {
-				return timeularDevice1.ProcessNotification(deviceName, data)
-			},
-		},
-		{
-			Name:               timeularDevice2.GetName(),
-			ServiceUUID:        timeularDevice2.GetServiceUUID(),
-			CharacteristicUUID: timeularDevice2.GetCharacteristicUUID(),
-			NotificationHandler: func(deviceName string, data []byte) error {
-				return timeularDevice2.ProcessNotification(deviceName, data)
-			},
-		},
-	}
-
 	// Start connecting to devices
 	fmt.Println("🔍 Searching for all BLE devices...")
 	fmt.Println("📱 Make sure your devices are powered on and nearby!")
-	if err := manager.ConnectDevices(deviceConfigs); err != nil {
+	if err := manager.Register(columbusDevice, timeularDevice1, timeularDevice2); err != nil {
 		log.Fatalf("❌ Failed to start device connection: %v", err)
 	}
 
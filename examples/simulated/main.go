@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/columbus"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/countries"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/simulation"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/timeular"
+)
+
+const penScript = `
+await subscription
+tap globe_hex 3A99 after 500ms
+tap globe_hex 3A9A after 1s
+drop connection after 500ms
+await subscription
+tap globe_hex 3A9B after 500ms
+`
+
+const trackerScript = `
+await subscription
+flip to side 3 after 700ms
+flip to side 5 after 1s
+`
+
+func main() {
+	fmt.Println("🧪 Simulated Devices Example")
+	fmt.Println("============================")
+	fmt.Println("This example runs the BLE manager against a virtual Columbus pen and Timeular tracker.")
+	fmt.Println("No Bluetooth hardware is required.")
+	fmt.Println("")
+
+	// Create the virtual radio and devices
+	adapter := bletest.NewAdapter()
+	pen := simulation.NewColumbusPen(bletest.MustParseAddress("C0:1B:05:00:00:01"))
+	tracker := simulation.NewTimeularTracker(timeular.DefaultDeviceName, bletest.MustParseAddress("7E:0A:12:00:00:01"))
+	adapter.AddPeripheral(pen.Peripheral())
+	adapter.AddPeripheral(tracker.Peripheral())
+
+	// Create devices and a manager on the virtual radio
+	columbusDevice := columbus.NewDevice()
+	timeularDevice := timeular.NewDevice()
+	manager := ble.NewManagerWithAdapter(adapter)
+	manager.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	columbusDevice.OnSignal(func(signal []byte) error {
+		countryHex, err := columbus.SignalToCountryHex(signal)
+		if err != nil {
+			return err
+		}
+
+		country, err := countries.ResolveFromHex(countryHex)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🌍 Country: %s\n", country.Name)
+		return nil
+	})
+
+	timeularDevice.OnSideChange(func(deviceName string, side byte) error {
+		fmt.Printf("🎲 %s side changed: %d\n", deviceName, side)
+		return nil
+	})
+
+	manager.SetReconnectHandler(func(deviceName, address string) {
+		fmt.Printf("🔁 %s [%s] is back\n", deviceName, address)
+	})
+
+	deviceConfigs := []ble.DeviceConfig{
+		{
+			Name:                columbusDevice.GetName(),
+			ServiceUUID:         columbusDevice.GetServiceUUID(),
+			CharacteristicUUID:  columbusDevice.GetCharacteristicUUID(),
+			NotificationHandler: columbusDevice.ProcessNotification,
+		},
+		{
+			Name:                timeularDevice.GetName(),
+			ServiceUUID:         timeularDevice.GetServiceUUID(),
+			CharacteristicUUID:  timeularDevice.GetCharacteristicUUID(),
+			NotificationHandler: timeularDevice.ProcessNotification,
+		},
+	}
+
+	// Play the scripts while the manager connects
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan error, 2)
+	go func() { done <- simulation.MustParseScript(penScript).Run(ctx, pen) }()
+	go func() { done <- simulation.MustParseScript(trackerScript).Run(ctx, tracker) }()
+
+	if err := manager.ConnectDevices(deviceConfigs); err != nil {
+		log.Fatalf("❌ Failed to connect: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			log.Fatalf("❌ Script failed: %v", err)
+		}
+	}
+
+	// Let the last notifications drain before shutting down
+	time.Sleep(100 * time.Millisecond)
+
+	fmt.Println("🧹 Cleaning up BLE connections...")
+	if err := manager.Close(); err != nil {
+		fmt.Printf("⚠️  Error during shutdown: %v\n", err)
+	}
+
+	fmt.Println("👋 Simulation finished!")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -27,6 +28,7 @@ func main() {
 
 	// Create a BLE manager
 	manager := ble.NewManager()
+	manager.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -103,29 +105,17 @@ func main() {
 		return nil
 	})
 
-	// Set up disconnect handler
+	// Set up disconnect handler. The device resets its own state.
 	manager.SetDisconnectHandler(func(deviceName, address string, err error) {
 		fmt.Printf("⚠️  Device %s [%s] disconnected: %v\n", deviceName, address, err)
 		fmt.Println("🔄 Will attempt to reconnect...")
-
-		// Reset device state
-		timeularDevice.Reset()
 	})
 
-	// Configure device for BLE manager
-	deviceConfig := ble.DeviceConfig{
-		Name:               timeularDevice.GetName(),
-		ServiceUUID:        timeularDevice.GetServiceUUID(),
-		CharacteristicUUID: timeularDevice.GetCharacteristicUUID(),
-		NotificationHandler: func(deviceName string, data []byte) error {
-			return timeularDevice.ProcessNotification(deviceName, data)
-		},
-	}
-
-	// Start connecting to device
+	// Start connecting to device. The side is polled at the configured
+	// interval, so the tracker does not need to send notifications.
 	fmt.Printf("🔍 Searching for Timeular tracker: %s\n", timeularDevice.GetName())
 	fmt.Println("📱 Make sure your Timeular device is turned on and nearby!")
-	if err := manager.ConnectDevices([]ble.DeviceConfig{deviceConfig}); err != nil {
+	if err := manager.Register(timeularDevice); err != nil {
 		log.Fatalf("❌ Failed to start device connection: %v", err)
 	}
 
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,12 +23,12 @@ func main() {
 
 	// Create a single Timeular device with custom configuration
 	timeularDevice := timeular.NewDeviceWithConfig(timeular.Config{
-		Name:         "Timeular Tra",
 		PollInterval: 500 * time.Millisecond, // Poll every 500ms for faster response
 	})
 
 	// Create a BLE manager
 	manager := ble.NewManager()
+	manager.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -50,23 +51,27 @@ func main() {
 		return nil
 	})
 
-	// Set up disconnect handler
+	// Set up disconnect handler. Polling stops by itself when the subscription
+	// withdraws the characteristic, and resumes on reconnect.
 	manager.SetDisconnectHandler(func(deviceName, address string, err error) {
 		fmt.Printf("⚠️  Device %s [%s] disconnected: %v\n", deviceName, address, err)
 		fmt.Println("🔄 Will attempt to reconnect...")
-
-		// Reset device state
-		timeularDevice.Reset()
 	})
 
-	// Configure device for BLE manager
+	// Configure device for BLE manager. The side is polled at the configured
+	// interval, so the tracker does not need to send notifications.
 	deviceConfig := ble.DeviceConfig{
-		Name:               timeularDevice.GetName(),
-		ServiceUUID:        timeularDevice.GetServiceUUID(),
-		CharacteristicUUID: timeularDevice.GetCharacteristicUUID(),
-		NotificationHandler: func(deviceName string, data []byte) error {
-			return timeularDevice.ProcessNotification(deviceName, data)
-		},
+		Name: timeularDevice.GetName(),
+		// Some trackers advertise a shortened name such as "Timeular Tra"
+		Match: ble.MatchNamePrefix("Timeular"),
+		Subscriptions: []ble.Subscription{{
+			ServiceUUID:        timeularDevice.GetServiceUUID(),
+			CharacteristicUUID: timeularDevice.GetCharacteristicUUID(),
+			Mode:               ble.ModePoll,
+			Reader: func(_ string, characteristic ble.Characteristic) {
+				timeularDevice.SetCharacteristic(characteristic)
+			},
+		}},
 	}
 
 	// Start connecting to device
@@ -0,0 +1,29 @@
+// Package logging holds the logging helpers shared by the toolkit packages.
+// All packages log through log/slog and are silent unless a logger is set.
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Discard returns a logger that drops every record
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// OrDiscard returns logger, or a discarding logger if logger is nil
+func OrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return Discard()
+	}
+	return logger
+}
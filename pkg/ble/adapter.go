@@ -0,0 +1,116 @@
+package ble
+
+import (
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// scanStopRetry is the pause between the StopScan calls of retryStopScan
+const scanStopRetry = 10 * time.Millisecond
+
+// Adapter abstracts the Bluetooth radio used by SimpleManager.
+// The tinygo bluetooth stack is wrapped by NewTinyGoAdapter; tests can plug in
+// an in-memory implementation such as the one in package bletest.
+type Adapter interface {
+	// Enable initializes the radio. It is called once before any scan or connect.
+	Enable() error
+
+	// Scan blocks and reports every advertisement to callback until StopScan is called.
+	Scan(callback func(result bluetooth.ScanResult)) error
+
+	// StopScan stops an in-progress scan. It may be called from within the scan callback.
+	StopScan() error
+
+	// Connect establishes a connection to the peripheral with the given address.
+	Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error)
+
+	// SetConnectHandler registers the callback for connection state changes.
+	// It must be called before Connect.
+	SetConnectHandler(handler func(address bluetooth.Address, connected bool))
+}
+
+// Connection is an established link to a remote peripheral
+type Connection interface {
+	// Address returns the address of the remote peripheral.
+	Address() bluetooth.Address
+
+	// DiscoverServices returns the requested services in the order given,
+	// or an error if any of them is missing.
+	DiscoverServices(uuids []bluetooth.UUID) ([]Service, error)
+
+	// Disconnect tears down the connection.
+	Disconnect() error
+}
+
+// DisconnectReporter is implemented by adapters that can tell why a
+// connection ended. SimpleManager then also receives disconnects through
+// SetDisconnectHandler, and the adapter reports each disconnect through
+// exactly one of the two handlers.
+type DisconnectReporter interface {
+	// SetDisconnectHandler registers the callback for ended connections. err
+	// is nil for an ordinary link loss and otherwise says why the connection
+	// was given up, e.g. ErrWatchdogLost. It must be called before Connect.
+	SetDisconnectHandler(handler func(address bluetooth.Address, err error))
+}
+
+// unwrapConnection returns the innermost connection of wrappers such as the
+// connections of an AdapterPool
+func unwrapConnection(conn Connection) Connection {
+	for {
+		wrapper, ok := conn.(interface{ Unwrap() Connection })
+		if !ok {
+			return conn
+		}
+		conn = wrapper.Unwrap()
+	}
+}
+
+// retryStopScan stops the scan of adapter whose Scan call closes done when it
+// returns. A scan that has not started yet cannot be stopped, so StopScan is
+// retried until then.
+func retryStopScan(adapter Adapter, done <-chan struct{}) {
+	ticker := time.NewTicker(scanStopRetry)
+	defer ticker.Stop()
+	for {
+		adapter.StopScan()
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RSSIReader is implemented by connections that can report the signal
+// strength of the connected peripheral. SimpleManager uses it for the RSSI
+// sampling of HealthConfig.
+type RSSIReader interface {
+	RSSI() (int16, error)
+}
+
+// Service is a GATT service discovered on a Connection
+type Service interface {
+	UUID() bluetooth.UUID
+
+	// DiscoverCharacteristics returns the requested characteristics in the
+	// order given, or an error if any of them is missing.
+	DiscoverCharacteristics(uuids []bluetooth.UUID) ([]Characteristic, error)
+}
+
+// Characteristic is a GATT characteristic discovered on a Service
+type Characteristic interface {
+	UUID() bluetooth.UUID
+
+	// EnableNotifications subscribes callback to value changes of the characteristic.
+	EnableNotifications(callback func(buf []byte)) error
+
+	// Read reads the current value into data and returns its length.
+	Read(data []byte) (int, error)
+
+	// Write writes p and waits for the peripheral to acknowledge it.
+	Write(p []byte) (int, error)
+
+	// WriteWithoutResponse writes p without waiting for an acknowledgement.
+	WriteWithoutResponse(p []byte) (int, error)
+}
@@ -0,0 +1,96 @@
+package ble
+
+// DefaultBufferSize is the number of notifications queued per subscription
+// when a DeviceConfig has no BufferSize
+const DefaultBufferSize = 10
+
+// OverflowPolicy decides what happens to a notification that arrives while
+// the queue of its subscription is full, i.e. while the handler falls behind
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest discards the incoming notification (the default)
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued notification to make room
+	OverflowDropOldest
+	// OverflowCoalesce queues only the latest notification, replacing any
+	// value the handler has not taken yet. BufferSize is ignored.
+	OverflowCoalesce
+	// OverflowBlock waits until the handler takes a queued notification.
+	// Nothing is lost, but the platform stack delivers notifications of all
+	// devices from one goroutine, so a slow handler stalls every device.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowCoalesce:
+		return "coalesce"
+	case OverflowBlock:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
+// bufferSize returns the queue capacity of the subscriptions of config
+func (c DeviceConfig) bufferSize() int {
+	switch {
+	case c.Overflow == OverflowCoalesce:
+		return 1
+	case c.BufferSize > 0:
+		return c.BufferSize
+	default:
+		return DefaultBufferSize
+	}
+}
+
+// enqueue hands data to ch according to the policy and returns the number of
+// notifications dropped to do so
+func (p OverflowPolicy) enqueue(ch chan []byte, data []byte) int {
+	switch p {
+	case OverflowBlock:
+		ch <- data
+		return 0
+
+	case OverflowDropOldest, OverflowCoalesce:
+		dropped := 0
+		for {
+			select {
+			case ch <- data:
+				return dropped
+			default:
+			}
+			// The handler may take the oldest value first, then the send just succeeds
+			select {
+			case <-ch:
+				dropped++
+			default:
+			}
+		}
+
+	default:
+		select {
+		case ch <- data:
+			return 0
+		default:
+			return 1
+		}
+	}
+}
+
+// DroppedNotifications returns how many notifications of a device were lost
+// to its overflow policy since the manager was created
+func (m *SimpleManager) DroppedNotifications(deviceName string) uint64 {
+	return m.metrics.dropped(deviceName)
+}
+
+// DroppedNotifications returns how many notifications of a device were lost
+// to its overflow policy
+func (m *Manager) DroppedNotifications(deviceName string) uint64 {
+	return m.simpleManager.DroppedNotifications(deviceName)
+}
@@ -0,0 +1,100 @@
+package ble
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// queued returns the first byte of every value in ch without blocking
+func queued(ch chan []byte) []byte {
+	var values []byte
+	for {
+		select {
+		case data := <-ch:
+			values = append(values, data[0])
+		default:
+			return values
+		}
+	}
+}
+
+func TestOverflowPolicyEnqueue(t *testing.T) {
+	tests := []struct {
+		policy  OverflowPolicy
+		dropped int
+		want    []byte
+	}{
+		{OverflowDropNewest, 1, []byte{1, 2}},
+		{OverflowDropOldest, 1, []byte{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			ch := make(chan []byte, 2)
+			dropped := 0
+			for i := byte(1); i <= 3; i++ {
+				dropped += tt.policy.enqueue(ch, []byte{i})
+			}
+			if dropped != tt.dropped {
+				t.Errorf("dropped %d, want %d", dropped, tt.dropped)
+			}
+			if got := queued(ch); !bytes.Equal(got, tt.want) {
+				t.Errorf("queued %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverflowCoalesceKeepsLatest(t *testing.T) {
+	config := DeviceConfig{Overflow: OverflowCoalesce, BufferSize: 8}
+	ch := make(chan []byte, config.bufferSize())
+
+	dropped := 0
+	for i := byte(1); i <= 3; i++ {
+		dropped += config.Overflow.enqueue(ch, []byte{i})
+	}
+	if dropped != 2 {
+		t.Errorf("dropped %d, want 2", dropped)
+	}
+	if got := queued(ch); !bytes.Equal(got, []byte{3}) {
+		t.Errorf("queued %v, want [3]", got)
+	}
+}
+
+func TestOverflowBlockWaitsForHandler(t *testing.T) {
+	ch := make(chan []byte, 1)
+	OverflowBlock.enqueue(ch, []byte{1})
+
+	done := make(chan int)
+	go func() { done <- OverflowBlock.enqueue(ch, []byte{2}) }()
+
+	select {
+	case <-done:
+		t.Fatal("enqueue did not block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-ch
+	if dropped := <-done; dropped != 0 {
+		t.Errorf("dropped %d, want 0", dropped)
+	}
+	if got := queued(ch); !bytes.Equal(got, []byte{2}) {
+		t.Errorf("queued %v, want [2]", got)
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	tests := []struct {
+		config DeviceConfig
+		want   int
+	}{
+		{DeviceConfig{}, DefaultBufferSize},
+		{DeviceConfig{BufferSize: 3}, 3},
+		{DeviceConfig{BufferSize: 3, Overflow: OverflowCoalesce}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.config.bufferSize(); got != tt.want {
+			t.Errorf("bufferSize of %d with %s = %d, want %d", tt.config.BufferSize, tt.config.Overflow, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package bletest provides an in-memory ble.Adapter for exercising the BLE
+// manager without a Bluetooth stack. Peripherals are registered on the
+// adapter, advertise while scanning and can be scripted to send
+// notifications or drop their connection.
+package bletest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"tinygo.org/x/bluetooth"
+)
+
+// DefaultAdvertisementInterval is how often peripherals advertise during a scan
+const DefaultAdvertisementInterval = 10 * time.Millisecond
+
+var (
+	errScanning    = errors.New("bletest: a scan is already in progress")
+	errNotScanning = errors.New("bletest: there is no scan in progress")
+)
+
+// Adapter is an in-memory implementation of ble.Adapter
+type Adapter struct {
+	// AdvertisementInterval is how often each advertising peripheral is reported while scanning
+	AdvertisementInterval time.Duration
+	// EnableErr, if set, is returned by Enable
+	EnableErr error
+
+	peripherals    map[string]*Peripheral
+	connectHandler func(address bluetooth.Address, connected bool)
+	scanStop       chan struct{}
+	enabled        bool
+	mu             sync.Mutex
+}
+
+// NewAdapter creates an empty in-memory adapter
+func NewAdapter() *Adapter {
+	return &Adapter{
+		AdvertisementInterval: DefaultAdvertisementInterval,
+		peripherals:           make(map[string]*Peripheral),
+	}
+}
+
+// AddPeripheral makes a peripheral visible to scans and connects. A
+// peripheral can be added to several adapters, as if it were in range of
+// several radios; its disconnects are reported by the adapter it is
+// connected through.
+func (a *Adapter) AddPeripheral(p *Peripheral) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.peripherals[p.Address.String()] = p
+}
+
+// RemovePeripheral drops the connection to a peripheral, if any, and removes it from the adapter
+func (a *Adapter) RemovePeripheral(p *Peripheral) {
+	p.Drop()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.peripherals, p.Address.String())
+}
+
+// IsEnabled reports whether Enable has been called successfully
+func (a *Adapter) IsEnabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enabled
+}
+
+// Enable implements ble.Adapter
+func (a *Adapter) Enable() error {
+	if a.EnableErr != nil {
+		return a.EnableErr
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled = true
+	return nil
+}
+
+// Scan implements ble.Adapter. Every advertising, unconnected peripheral is
+// reported once per AdvertisementInterval until StopScan is called.
+func (a *Adapter) Scan(callback func(result bluetooth.ScanResult)) error {
+	a.mu.Lock()
+	if a.scanStop != nil {
+		a.mu.Unlock()
+		return errScanning
+	}
+	stop := make(chan struct{})
+	a.scanStop = stop
+	interval := a.AdvertisementInterval
+	a.mu.Unlock()
+
+	if interval <= 0 {
+		interval = DefaultAdvertisementInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, result := range a.advertisements() {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			callback(result)
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// advertisements returns a scan result for every peripheral currently advertising
+func (a *Adapter) advertisements() []bluetooth.ScanResult {
+	a.mu.Lock()
+	peripherals := make([]*Peripheral, 0, len(a.peripherals))
+	for _, p := range a.peripherals {
+		peripherals = append(peripherals, p)
+	}
+	a.mu.Unlock()
+
+	results := make([]bluetooth.ScanResult, 0, len(peripherals))
+	for _, p := range peripherals {
+		if result, ok := p.scanResult(); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// StopScan implements ble.Adapter
+func (a *Adapter) StopScan() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.scanStop == nil {
+		return errNotScanning
+	}
+	close(a.scanStop)
+	a.scanStop = nil
+	return nil
+}
+
+// Connect implements ble.Adapter
+func (a *Adapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (ble.Connection, error) {
+	a.mu.Lock()
+	p, ok := a.peripherals[address.String()]
+	a.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("bletest: no peripheral with address %s", address.String())
+	}
+
+	conn, err := p.connect(a)
+	if err != nil {
+		return nil, err
+	}
+
+	a.notifyConnect(address, true)
+	return conn, nil
+}
+
+// SetConnectHandler implements ble.Adapter
+func (a *Adapter) SetConnectHandler(handler func(address bluetooth.Address, connected bool)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.connectHandler = handler
+}
+
+// notifyConnect forwards a connection state change to the registered handler
+func (a *Adapter) notifyConnect(address bluetooth.Address, connected bool) {
+	a.mu.Lock()
+	handler := a.connectHandler
+	a.mu.Unlock()
+
+	if handler != nil {
+		handler(address, connected)
+	}
+}
@@ -0,0 +1,503 @@
+package bletest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"tinygo.org/x/bluetooth"
+)
+
+var (
+	errNotConnected  = errors.New("bletest: peripheral not connected")
+	errNotResponding = errors.New("bletest: peripheral not responding")
+)
+
+// Peripheral is a virtual BLE peripheral served by an in-memory Adapter
+type Peripheral struct {
+	Address bluetooth.Address
+
+	name             string
+	rssi             int16
+	advertising      bool
+	serviceUUIDs     []bluetooth.UUID
+	manufacturerData []bluetooth.ManufacturerDataElement
+	services         []*Service
+	connectErr       error
+	unresponsive     bool
+	connection       *connection
+	mu               sync.Mutex
+}
+
+// NewPeripheral creates an advertising peripheral with the given local name and address
+func NewPeripheral(name string, address bluetooth.Address) *Peripheral {
+	return &Peripheral{
+		Address:     address,
+		name:        name,
+		rssi:        -50,
+		advertising: true,
+	}
+}
+
+// MustParseAddress parses a MAC address in 11:22:33:AA:BB:CC format (a device
+// UUID on macOS) and panics if it is invalid
+func MustParseAddress(s string) bluetooth.Address {
+	var address bluetooth.Address
+	address.Set(s)
+	if address.String() == (bluetooth.Address{}).String() {
+		panic(fmt.Sprintf("bletest: invalid address %q", s))
+	}
+	return address
+}
+
+// Name returns the advertised local name
+func (p *Peripheral) Name() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.name
+}
+
+// SetName changes the advertised local name
+func (p *Peripheral) SetName(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.name = name
+}
+
+// SetRSSI changes the signal strength reported in scan results
+func (p *Peripheral) SetRSSI(rssi int16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rssi = rssi
+}
+
+// SetAdvertising controls whether the peripheral shows up in scans
+func (p *Peripheral) SetAdvertising(advertising bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.advertising = advertising
+}
+
+// AdvertiseServiceUUID adds a service UUID to the advertisement payload
+func (p *Peripheral) AdvertiseServiceUUID(uuid bluetooth.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.serviceUUIDs = append(p.serviceUUIDs, uuid)
+}
+
+// SetManufacturerData adds or replaces manufacturer data in the advertisement payload
+func (p *Peripheral) SetManufacturerData(companyID uint16, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element := bluetooth.ManufacturerDataElement{CompanyID: companyID, Data: append([]byte(nil), data...)}
+	for i := range p.manufacturerData {
+		if p.manufacturerData[i].CompanyID == companyID {
+			p.manufacturerData[i] = element
+			return
+		}
+	}
+	p.manufacturerData = append(p.manufacturerData, element)
+}
+
+// SetConnectError makes subsequent connection attempts fail with err (nil to succeed again)
+func (p *Peripheral) SetConnectError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connectErr = err
+}
+
+// SetUnresponsive simulates a half-dead link: the connection stays up, but
+// reads and writes fail and notifications are lost until it is set back to false
+func (p *Peripheral) SetUnresponsive(unresponsive bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unresponsive = unresponsive
+}
+
+// AddService adds a GATT service to the peripheral
+func (p *Peripheral) AddService(uuid bluetooth.UUID) *Service {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	service := &Service{uuid: uuid, peripheral: p}
+	p.services = append(p.services, service)
+	return service
+}
+
+// IsConnected reports whether a central is connected to the peripheral
+func (p *Peripheral) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.connection != nil
+}
+
+// Drop simulates a link loss: the connection is torn down and the adapter
+// reports the disconnect. It is a no-op if the peripheral is not connected.
+func (p *Peripheral) Drop() {
+	if adapter, ok := p.disconnect(); ok {
+		adapter.notifyConnect(p.Address, false)
+	}
+}
+
+// scanResult returns the advertisement of the peripheral, if it is currently advertising
+func (p *Peripheral) scanResult() (bluetooth.ScanResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.advertising || p.connection != nil {
+		return bluetooth.ScanResult{}, false
+	}
+
+	return bluetooth.ScanResult{
+		Address: p.Address,
+		RSSI:    p.rssi,
+		AdvertisementPayload: &advertisement{
+			localName:        p.name,
+			serviceUUIDs:     append([]bluetooth.UUID(nil), p.serviceUUIDs...),
+			manufacturerData: append([]bluetooth.ManufacturerDataElement(nil), p.manufacturerData...),
+		},
+	}, true
+}
+
+// connect establishes a new connection to the peripheral through adapter
+func (p *Peripheral) connect(adapter *Adapter) (*connection, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.connectErr != nil {
+		return nil, p.connectErr
+	}
+	if p.connection != nil {
+		return nil, fmt.Errorf("bletest: peripheral %s already connected", p.Address.String())
+	}
+
+	p.connection = &connection{peripheral: p, adapter: adapter}
+	return p.connection, nil
+}
+
+// disconnect tears down the current connection and returns the adapter it
+// was made through, if there was one
+func (p *Peripheral) disconnect() (*Adapter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.connection == nil {
+		return nil, false
+	}
+
+	adapter := p.connection.adapter
+	p.connection = nil
+	for _, service := range p.services {
+		for _, characteristic := range service.characteristics {
+			characteristic.callback = nil
+		}
+	}
+	return adapter, true
+}
+
+// Service is a GATT service of a virtual peripheral
+type Service struct {
+	uuid            bluetooth.UUID
+	peripheral      *Peripheral
+	characteristics []*Characteristic
+}
+
+// UUID returns the service UUID
+func (s *Service) UUID() bluetooth.UUID {
+	return s.uuid
+}
+
+// AddCharacteristic adds a characteristic to the service
+func (s *Service) AddCharacteristic(uuid bluetooth.UUID) *Characteristic {
+	s.peripheral.mu.Lock()
+	defer s.peripheral.mu.Unlock()
+
+	characteristic := &Characteristic{uuid: uuid, peripheral: s.peripheral}
+	s.characteristics = append(s.characteristics, characteristic)
+	return characteristic
+}
+
+// Characteristic is a GATT characteristic of a virtual peripheral
+type Characteristic struct {
+	uuid        bluetooth.UUID
+	peripheral  *Peripheral
+	callback    func(buf []byte)
+	value       []byte
+	onWrite     func(data []byte)
+	onSubscribe func()
+}
+
+// UUID returns the characteristic UUID
+func (c *Characteristic) UUID() bluetooth.UUID {
+	return c.uuid
+}
+
+// IsSubscribed reports whether the connected central has enabled notifications
+func (c *Characteristic) IsSubscribed() bool {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	return c.callback != nil
+}
+
+// SetValue sets the value returned when the central reads the characteristic
+func (c *Characteristic) SetValue(data []byte) {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	c.value = append([]byte(nil), data...)
+}
+
+// Value returns the current value, i.e. the last one set or written by the central
+func (c *Characteristic) Value() []byte {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	return append([]byte(nil), c.value...)
+}
+
+// OnWrite sets a handler called with every value the central writes, e.g. to
+// answer commands with Notify
+func (c *Characteristic) OnWrite(handler func(data []byte)) {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	c.onWrite = handler
+}
+
+// OnSubscribe sets a handler called whenever the central enables
+// notifications, e.g. to interfere with the central while it sets up the connection
+func (c *Characteristic) OnSubscribe(handler func()) {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	c.onSubscribe = handler
+}
+
+// Notify sends a notification to the connected central. It fails if no
+// central is connected or notifications have not been enabled.
+func (c *Characteristic) Notify(data []byte) error {
+	c.peripheral.mu.Lock()
+	if c.peripheral.connection == nil {
+		c.peripheral.mu.Unlock()
+		return errNotConnected
+	}
+	callback := c.callback
+	unresponsive := c.peripheral.unresponsive
+	c.peripheral.mu.Unlock()
+
+	if callback == nil {
+		return fmt.Errorf("bletest: notifications not enabled on %s", c.uuid.String())
+	}
+	if unresponsive {
+		return nil // lost on the way, the peripheral cannot tell
+	}
+
+	callback(append([]byte(nil), data...))
+	return nil
+}
+
+// connection implements ble.Connection for a virtual peripheral
+type connection struct {
+	peripheral *Peripheral
+	adapter    *Adapter
+}
+
+func (c *connection) Address() bluetooth.Address {
+	return c.peripheral.Address
+}
+
+func (c *connection) DiscoverServices(uuids []bluetooth.UUID) ([]ble.Service, error) {
+	if !c.active() {
+		return nil, errNotConnected
+	}
+
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+
+	if len(uuids) == 0 {
+		result := make([]ble.Service, len(c.peripheral.services))
+		for i, service := range c.peripheral.services {
+			result[i] = &remoteService{service: service, conn: c}
+		}
+		return result, nil
+	}
+
+	result := make([]ble.Service, len(uuids))
+	for i, uuid := range uuids {
+		for _, service := range c.peripheral.services {
+			if service.uuid == uuid {
+				result[i] = &remoteService{service: service, conn: c}
+				break
+			}
+		}
+		if result[i] == nil {
+			return nil, fmt.Errorf("bletest: service %s not found", uuid.String())
+		}
+	}
+	return result, nil
+}
+
+// RSSI implements ble.RSSIReader with the signal strength set by SetRSSI
+func (c *connection) RSSI() (int16, error) {
+	if !c.active() {
+		return 0, errNotConnected
+	}
+
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	return c.peripheral.rssi, nil
+}
+
+func (c *connection) Disconnect() error {
+	if !c.active() {
+		return errNotConnected
+	}
+	c.peripheral.Drop()
+	return nil
+}
+
+// active reports whether this connection is still the peripheral's current one
+func (c *connection) active() bool {
+	c.peripheral.mu.Lock()
+	defer c.peripheral.mu.Unlock()
+	return c.peripheral.connection == c
+}
+
+// remoteService implements ble.Service as seen through a connection
+type remoteService struct {
+	service *Service
+	conn    *connection
+}
+
+func (s *remoteService) UUID() bluetooth.UUID {
+	return s.service.uuid
+}
+
+func (s *remoteService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]ble.Characteristic, error) {
+	if !s.conn.active() {
+		return nil, errNotConnected
+	}
+
+	p := s.service.peripheral
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(uuids) == 0 {
+		result := make([]ble.Characteristic, len(s.service.characteristics))
+		for i, characteristic := range s.service.characteristics {
+			result[i] = &remoteCharacteristic{characteristic: characteristic, conn: s.conn}
+		}
+		return result, nil
+	}
+
+	result := make([]ble.Characteristic, len(uuids))
+	for i, uuid := range uuids {
+		for _, characteristic := range s.service.characteristics {
+			if characteristic.uuid == uuid {
+				result[i] = &remoteCharacteristic{characteristic: characteristic, conn: s.conn}
+				break
+			}
+		}
+		if result[i] == nil {
+			return nil, fmt.Errorf("bletest: characteristic %s not found", uuid.String())
+		}
+	}
+	return result, nil
+}
+
+// remoteCharacteristic implements ble.Characteristic as seen through a connection
+type remoteCharacteristic struct {
+	characteristic *Characteristic
+	conn           *connection
+}
+
+func (c *remoteCharacteristic) UUID() bluetooth.UUID {
+	return c.characteristic.uuid
+}
+
+func (c *remoteCharacteristic) EnableNotifications(callback func(buf []byte)) error {
+	if !c.conn.active() {
+		return errNotConnected
+	}
+
+	p := c.characteristic.peripheral
+	p.mu.Lock()
+	c.characteristic.callback = callback
+	onSubscribe := c.characteristic.onSubscribe
+	p.mu.Unlock()
+
+	if onSubscribe != nil {
+		onSubscribe()
+	}
+	return nil
+}
+
+func (c *remoteCharacteristic) Read(data []byte) (int, error) {
+	if !c.conn.active() {
+		return 0, errNotConnected
+	}
+
+	p := c.characteristic.peripheral
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unresponsive {
+		return 0, errNotResponding
+	}
+	return copy(data, c.characteristic.value), nil
+}
+
+func (c *remoteCharacteristic) Write(p []byte) (int, error) {
+	if !c.conn.active() {
+		return 0, errNotConnected
+	}
+
+	data := append([]byte(nil), p...)
+	peripheral := c.characteristic.peripheral
+	peripheral.mu.Lock()
+	if peripheral.unresponsive {
+		peripheral.mu.Unlock()
+		return 0, errNotResponding
+	}
+	c.characteristic.value = data
+	handler := c.characteristic.onWrite
+	peripheral.mu.Unlock()
+
+	if handler != nil {
+		handler(append([]byte(nil), data...))
+	}
+	return len(p), nil
+}
+
+func (c *remoteCharacteristic) WriteWithoutResponse(p []byte) (int, error) {
+	return c.Write(p)
+}
+
+// advertisement implements bluetooth.AdvertisementPayload for scan results
+type advertisement struct {
+	localName        string
+	serviceUUIDs     []bluetooth.UUID
+	manufacturerData []bluetooth.ManufacturerDataElement
+}
+
+func (a *advertisement) LocalName() string {
+	return a.localName
+}
+
+func (a *advertisement) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, u := range a.serviceUUIDs {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *advertisement) Bytes() []byte {
+	return nil
+}
+
+func (a *advertisement) ManufacturerData() []bluetooth.ManufacturerDataElement {
+	return a.manufacturerData
+}
+
+func (a *advertisement) ServiceData() []bluetooth.ServiceDataElement {
+	return nil
+}
@@ -0,0 +1,668 @@
+//go:build linux
+
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"tinygo.org/x/bluetooth"
+)
+
+const (
+	// bluezConnectTimeout bounds Connect when the params give no timeout
+	bluezConnectTimeout = 10 * time.Second
+
+	// bluezResolveTimeout bounds the wait for BlueZ to resolve the services of a new connection
+	bluezResolveTimeout = 10 * time.Second
+
+	// bluezResolvePoll is the pause between checks of ServicesResolved
+	bluezResolvePoll = 10 * time.Millisecond
+)
+
+// bluezAdapter implements Adapter directly on the BlueZ D-Bus API. tinygo
+// bluetooth can only open hci0, while this adapter can be any one present,
+// e.g. hci1 for a USB dongle.
+type bluezAdapter struct {
+	linkHandlers
+	id   string
+	path dbus.ObjectPath // e.g. /org/bluez/hci1
+
+	// conn is a private bus connection, so that the match rules of scans and
+	// notifications cannot clash with other users of the system bus
+	conn    *dbus.Conn
+	scan    *bluezScan // in-progress scan, nil if none
+	stateMu sync.Mutex
+}
+
+// bluezScan is an in-progress scan, stopped by closing stop once
+type bluezScan struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewAdapterByID returns the BlueZ adapter with the given ID, e.g. "hci1" for
+// a USB dongle. See AdapterIDs for the adapters present.
+func NewAdapterByID(id string) (Adapter, error) {
+	path := dbus.ObjectPath("/org/bluez/" + id)
+	if id == "" || strings.Contains(id, "/") || !path.IsValid() {
+		return nil, fmt.Errorf("invalid adapter ID %q", id)
+	}
+	return &bluezAdapter{id: id, path: path}, nil
+}
+
+// AdapterIDs returns the IDs of the BlueZ adapters present, e.g. ["hci0", "hci1"]
+func AdapterIDs() ([]string, error) {
+	objects, err := bluezObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for path, interfaces := range objects {
+		if _, ok := interfaces["org.bluez.Adapter1"]; ok {
+			ids = append(ids, strings.TrimPrefix(string(path), "/org/bluez/"))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (a *bluezAdapter) Enable() error {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	if a.conn != nil {
+		return nil
+	}
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to D-Bus: %v", err)
+	}
+	adapter := conn.Object("org.bluez", a.path)
+	powered, err := adapter.GetProperty("org.bluez.Adapter1.Powered")
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("no BlueZ adapter %s: %v", a.id, err)
+	}
+	if on, _ := powered.Value().(bool); !on {
+		if err := adapter.SetProperty("org.bluez.Adapter1.Powered", dbus.MakeVariant(true)); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to power on adapter %s: %v", a.id, err)
+		}
+	}
+
+	a.conn = conn
+	return nil
+}
+
+// bus returns the connection opened by Enable
+func (a *bluezAdapter) bus() (*dbus.Conn, error) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	if a.conn == nil {
+		return nil, fmt.Errorf("adapter %s is not enabled", a.id)
+	}
+	return a.conn, nil
+}
+
+// owns reports whether path is a device of this adapter
+func (a *bluezAdapter) owns(path dbus.ObjectPath) bool {
+	rest, ok := strings.CutPrefix(string(path), string(a.path)+"/")
+	return ok && !strings.Contains(rest, "/")
+}
+
+// devicePath returns the BlueZ object path of a device on this adapter,
+// e.g. /org/bluez/hci1/dev_AA_BB_CC_DD_EE_FF
+func (a *bluezAdapter) devicePath(address bluetooth.Address) dbus.ObjectPath {
+	return dbus.ObjectPath(string(a.path) + "/dev_" + strings.ReplaceAll(address.MAC.String(), ":", "_"))
+}
+
+// Scan reports the advertisements received by this adapter only. BlueZ
+// announces the devices of all adapters on the bus, so signals are matched
+// against the adapter's object path.
+func (a *bluezAdapter) Scan(callback func(result bluetooth.ScanResult)) error {
+	conn, err := a.bus()
+	if err != nil {
+		return err
+	}
+
+	a.stateMu.Lock()
+	if a.scan != nil {
+		a.stateMu.Unlock()
+		return fmt.Errorf("adapter %s is already scanning", a.id)
+	}
+	scan := &bluezScan{stop: make(chan struct{})}
+	a.scan = scan
+	a.stateMu.Unlock()
+
+	// Cleared only once the scan has returned, so that the StopDiscovery of
+	// a stopping scan cannot end a scan started after it
+	defer func() {
+		a.stateMu.Lock()
+		a.scan = nil
+		a.stateMu.Unlock()
+	}()
+
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	for _, match := range a.scanMatches() {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			return fmt.Errorf("failed to add D-Bus match rule: %v", err)
+		}
+		defer conn.RemoveMatchSignal(match...)
+	}
+
+	adapter := conn.Object("org.bluez", a.path)
+	filter := map[string]interface{}{"Transport": "le", "DuplicateData": true}
+	if err := adapter.Call("org.bluez.Adapter1.SetDiscoveryFilter", 0, filter).Err; err != nil {
+		return fmt.Errorf("failed to set discovery filter: %v", err)
+	}
+	defer adapter.Call("org.bluez.Adapter1.SetDiscoveryFilter", 0, map[string]interface{}{})
+
+	// Devices BlueZ already knows only report what changes
+	devices := make(map[dbus.ObjectPath]map[string]dbus.Variant)
+	objects, err := managedObjects(conn)
+	if err != nil {
+		return err
+	}
+	for path, interfaces := range objects {
+		if properties, ok := interfaces["org.bluez.Device1"]; ok && a.owns(path) {
+			devices[path] = properties
+		}
+	}
+
+	if err := adapter.Call("org.bluez.Adapter1.StartDiscovery", 0).Err; err != nil {
+		return fmt.Errorf("failed to start discovery on %s: %v", a.id, err)
+	}
+	defer adapter.Call("org.bluez.Adapter1.StopDiscovery", 0)
+
+	for {
+		// A stop takes precedence over signals still queued
+		select {
+		case <-scan.stop:
+			return nil
+		default:
+		}
+
+		select {
+		case <-scan.stop:
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("scan on %s lost its D-Bus connection", a.id)
+			}
+			properties, ok := a.advertised(devices, sig)
+			if !ok {
+				continue
+			}
+			if result, ok := bluezScanResult(properties); ok {
+				callback(result)
+			}
+		}
+	}
+}
+
+// scanMatches selects the signals that announce devices and their advertisements
+func (a *bluezAdapter) scanMatches() [][]dbus.MatchOption {
+	return [][]dbus.MatchOption{
+		{
+			dbus.WithMatchInterface("org.freedesktop.DBus.ObjectManager"),
+			dbus.WithMatchMember("InterfacesAdded"),
+		},
+		{
+			dbus.WithMatchPathNamespace(a.path),
+			dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"),
+			dbus.WithMatchArg(0, "org.bluez.Device1"),
+		},
+	}
+}
+
+// advertised applies sig to the known devices and returns the properties of
+// the device it is about, if sig reports an advertisement received by this adapter
+func (a *bluezAdapter) advertised(devices map[dbus.ObjectPath]map[string]dbus.Variant, sig *dbus.Signal) (map[string]dbus.Variant, bool) {
+	if len(sig.Body) < 2 {
+		return nil, false
+	}
+
+	switch sig.Name {
+	case "org.freedesktop.DBus.ObjectManager.InterfacesAdded":
+		path, ok := sig.Body[0].(dbus.ObjectPath)
+		if !ok || !a.owns(path) {
+			return nil, false
+		}
+		interfaces, ok := sig.Body[1].(map[string]map[string]dbus.Variant)
+		if !ok {
+			return nil, false
+		}
+		properties, ok := interfaces["org.bluez.Device1"]
+		if !ok {
+			return nil, false
+		}
+		devices[path] = properties
+		return properties, true
+
+	case "org.freedesktop.DBus.Properties.PropertiesChanged":
+		if iface, _ := sig.Body[0].(string); iface != "org.bluez.Device1" || !a.owns(sig.Path) {
+			return nil, false
+		}
+		changes, ok := sig.Body[1].(map[string]dbus.Variant)
+		if !ok {
+			return nil, false
+		}
+		properties, ok := devices[sig.Path]
+		if !ok {
+			return nil, false
+		}
+		for name, value := range changes {
+			properties[name] = value
+		}
+
+		// Other changes, e.g. of Connected, are no advertisements
+		for _, name := range []string{"RSSI", "ManufacturerData", "ServiceData"} {
+			if _, ok := changes[name]; ok {
+				return properties, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// bluezScanResult converts the Device1 properties of an advertiser
+func bluezScanResult(properties map[string]dbus.Variant) (bluetooth.ScanResult, bool) {
+	text, _ := properties["Address"].Value().(string)
+	mac, err := bluetooth.ParseMAC(text)
+	if err != nil {
+		return bluetooth.ScanResult{}, false
+	}
+	address := bluetooth.Address{MACAddress: bluetooth.MACAddress{MAC: mac}}
+	address.SetRandom(properties["AddressType"].Value() == "random")
+
+	payload := &bluezAdvertisement{}
+	payload.localName, _ = properties["Name"].Value().(string)
+	uuids, _ := properties["UUIDs"].Value().([]string)
+	for _, text := range uuids {
+		if uuid, err := bluetooth.ParseUUID(text); err == nil {
+			payload.serviceUUIDs = append(payload.serviceUUIDs, uuid)
+		}
+	}
+	manufacturerData, _ := properties["ManufacturerData"].Value().(map[uint16]dbus.Variant)
+	for companyID, value := range manufacturerData {
+		if data, ok := value.Value().([]byte); ok {
+			payload.manufacturerData = append(payload.manufacturerData, bluetooth.ManufacturerDataElement{CompanyID: companyID, Data: data})
+		}
+	}
+	sort.Slice(payload.manufacturerData, func(i, j int) bool {
+		return payload.manufacturerData[i].CompanyID < payload.manufacturerData[j].CompanyID
+	})
+	serviceData, _ := properties["ServiceData"].Value().(map[string]dbus.Variant)
+	for text, value := range serviceData {
+		uuid, err := bluetooth.ParseUUID(text)
+		data, ok := value.Value().([]byte)
+		if err == nil && ok {
+			payload.serviceData = append(payload.serviceData, bluetooth.ServiceDataElement{UUID: uuid, Data: data})
+		}
+	}
+	sort.Slice(payload.serviceData, func(i, j int) bool {
+		return payload.serviceData[i].UUID.String() < payload.serviceData[j].UUID.String()
+	})
+
+	rssi, _ := properties["RSSI"].Value().(int16)
+	return bluetooth.ScanResult{Address: address, RSSI: rssi, AdvertisementPayload: payload}, true
+}
+
+// bluezAdvertisement implements bluetooth.AdvertisementPayload. BlueZ only
+// exports the parsed fields, so Bytes returns nil.
+type bluezAdvertisement struct {
+	localName        string
+	serviceUUIDs     []bluetooth.UUID
+	manufacturerData []bluetooth.ManufacturerDataElement
+	serviceData      []bluetooth.ServiceDataElement
+}
+
+func (p *bluezAdvertisement) LocalName() string {
+	return p.localName
+}
+
+func (p *bluezAdvertisement) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, candidate := range p.serviceUUIDs {
+		if candidate == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *bluezAdvertisement) Bytes() []byte {
+	return nil
+}
+
+func (p *bluezAdvertisement) ManufacturerData() []bluetooth.ManufacturerDataElement {
+	return p.manufacturerData
+}
+
+func (p *bluezAdvertisement) ServiceData() []bluetooth.ServiceDataElement {
+	return p.serviceData
+}
+
+func (a *bluezAdapter) StopScan() error {
+	a.stateMu.Lock()
+	scan := a.scan
+	a.stateMu.Unlock()
+
+	if scan == nil {
+		return fmt.Errorf("adapter %s is not scanning", a.id)
+	}
+	scan.once.Do(func() { close(scan.stop) })
+	return nil
+}
+
+func (a *bluezAdapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	conn, err := a.bus()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &bluezConnection{
+		conn:    conn,
+		adapter: a,
+		address: address,
+		path:    a.devicePath(address),
+		done:    make(chan struct{}),
+	}
+
+	// Watched before connecting, so that a link lost right away is not missed
+	cancel, err := watchdog.watch(c.path, func(err error) {
+		c.release()
+		a.lost(address, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(params.ConnectionTimeout) * 625 * time.Microsecond
+	if timeout == 0 {
+		timeout = bluezConnectTimeout
+	}
+	ctx, cancelConnect := context.WithTimeout(context.Background(), timeout)
+	defer cancelConnect()
+
+	device := conn.Object("org.bluez", c.path)
+	if err := device.CallWithContext(ctx, "org.bluez.Device1.Connect", 0).Err; err != nil {
+		cancel()
+		// Abort the attempt BlueZ may still be making
+		device.Call("org.bluez.Device1.Disconnect", 0)
+		return nil, fmt.Errorf("failed to connect to %s: %v", address.String(), err)
+	}
+	a.setWatchdog(address, cancel)
+
+	return c, nil
+}
+
+// bluezConnection implements Connection for a device of a bluezAdapter
+type bluezConnection struct {
+	conn    *dbus.Conn
+	adapter *bluezAdapter
+	address bluetooth.Address
+	path    dbus.ObjectPath
+
+	// done is closed when the connection ends and stops its notifications
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func (c *bluezConnection) Address() bluetooth.Address {
+	return c.address
+}
+
+// RSSI implements RSSIReader. BlueZ takes the value from advertisements, so it
+// is only updated while the peripheral keeps advertising during the connection.
+func (c *bluezConnection) RSSI() (int16, error) {
+	variant, err := c.conn.Object("org.bluez", c.path).GetProperty("org.bluez.Device1.RSSI")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read RSSI: %v", err)
+	}
+	rssi, ok := variant.Value().(int16)
+	if !ok {
+		return 0, errors.New("RSSI not available")
+	}
+	return rssi, nil
+}
+
+func (c *bluezConnection) DiscoverServices(uuids []bluetooth.UUID) ([]Service, error) {
+	if err := c.awaitServicesResolved(); err != nil {
+		return nil, err
+	}
+
+	objects, err := managedObjects(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	found, err := bluezChildren(objects, string(c.path)+"/service", "org.bluez.GattService1", uuids, "service")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Service, len(found))
+	for i, object := range found {
+		result[i] = bluezService{connection: c, path: object.path, uuid: object.uuid}
+	}
+	return result, nil
+}
+
+// awaitServicesResolved waits until BlueZ has discovered the services of the device
+func (c *bluezConnection) awaitServicesResolved() error {
+	device := c.conn.Object("org.bluez", c.path)
+	deadline := time.Now().Add(bluezResolveTimeout)
+	for {
+		resolved, err := device.GetProperty("org.bluez.Device1.ServicesResolved")
+		if err != nil {
+			return fmt.Errorf("failed to read ServicesResolved: %v", err)
+		}
+		if ok, _ := resolved.Value().(bool); ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("services of %s not resolved within %s", c.address.String(), bluezResolveTimeout)
+		}
+		time.Sleep(bluezResolvePoll)
+	}
+}
+
+func (c *bluezConnection) Disconnect() error {
+	c.adapter.stopWatchdog(c.address)
+	c.release()
+	return c.conn.Object("org.bluez", c.path).Call("org.bluez.Device1.Disconnect", 0).Err
+}
+
+// release ends the notifications of the connection
+func (c *bluezConnection) release() {
+	c.doneOnce.Do(func() { close(c.done) })
+}
+
+// bluezService implements Service on top of a BlueZ GattService1 object
+type bluezService struct {
+	connection *bluezConnection
+	path       dbus.ObjectPath
+	uuid       bluetooth.UUID
+}
+
+func (s bluezService) UUID() bluetooth.UUID {
+	return s.uuid
+}
+
+func (s bluezService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]Characteristic, error) {
+	objects, err := managedObjects(s.connection.conn)
+	if err != nil {
+		return nil, err
+	}
+	found, err := bluezChildren(objects, string(s.path)+"/char", "org.bluez.GattCharacteristic1", uuids, "characteristic")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Characteristic, len(found))
+	for i, object := range found {
+		result[i] = bluezCharacteristic{connection: s.connection, path: object.path, uuid: object.uuid}
+	}
+	return result, nil
+}
+
+// bluezCharacteristic implements Characteristic on top of a BlueZ GattCharacteristic1 object
+type bluezCharacteristic struct {
+	connection *bluezConnection
+	path       dbus.ObjectPath
+	uuid       bluetooth.UUID
+}
+
+func (c bluezCharacteristic) UUID() bluetooth.UUID {
+	return c.uuid
+}
+
+// EnableNotifications delivers value changes to callback until the connection ends
+func (c bluezCharacteristic) EnableNotifications(callback func(buf []byte)) error {
+	conn := c.connection.conn
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(c.path),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, "org.bluez.GattCharacteristic1"),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return fmt.Errorf("failed to add D-Bus match rule: %v", err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	if err := conn.Object("org.bluez", c.path).Call("org.bluez.GattCharacteristic1.StartNotify", 0).Err; err != nil {
+		conn.RemoveSignal(signals)
+		conn.RemoveMatchSignal(match...)
+		return fmt.Errorf("failed to enable notifications: %v", err)
+	}
+
+	go func() {
+		defer conn.RemoveMatchSignal(match...)
+		defer conn.RemoveSignal(signals)
+		for {
+			select {
+			case <-c.connection.done:
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				if value, ok := characteristicValue(c.path, sig); ok {
+					callback(value)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// characteristicValue returns the new value of the characteristic at path if sig reports one
+func characteristicValue(path dbus.ObjectPath, sig *dbus.Signal) ([]byte, bool) {
+	if sig.Path != path || sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return nil, false
+	}
+	if iface, _ := sig.Body[0].(string); iface != "org.bluez.GattCharacteristic1" {
+		return nil, false
+	}
+	changes, _ := sig.Body[1].(map[string]dbus.Variant)
+	value, ok := changes["Value"].Value().([]byte)
+	return value, ok
+}
+
+func (c bluezCharacteristic) Read(data []byte) (int, error) {
+	var value []byte
+	err := c.connection.conn.Object("org.bluez", c.path).
+		Call("org.bluez.GattCharacteristic1.ReadValue", 0, map[string]interface{}{}).Store(&value)
+	if err != nil {
+		return 0, err
+	}
+	return copy(data, value), nil
+}
+
+// Write sends a write request, which the peripheral acknowledges
+func (c bluezCharacteristic) Write(p []byte) (int, error) {
+	return c.writeValue(p, "request")
+}
+
+// WriteWithoutResponse sends a write command, which is not acknowledged
+func (c bluezCharacteristic) WriteWithoutResponse(p []byte) (int, error) {
+	return c.writeValue(p, "command")
+}
+
+func (c bluezCharacteristic) writeValue(p []byte, writeType string) (int, error) {
+	options := map[string]interface{}{"type": writeType}
+	err := c.connection.conn.Object("org.bluez", c.path).Call("org.bluez.GattCharacteristic1.WriteValue", 0, p, options).Err
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// bluezObject is a GATT object found by bluezChildren
+type bluezObject struct {
+	path dbus.ObjectPath
+	uuid bluetooth.UUID
+}
+
+// bluezChildren returns the objects implementing iface whose path starts
+// with prefix, in the order of uuids, or all of them sorted by path if uuids
+// is empty. A requested UUID that is missing is an error.
+func bluezChildren(objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant, prefix, iface string, uuids []bluetooth.UUID, kind string) ([]bluezObject, error) {
+	var children []bluezObject
+	for path, interfaces := range objects {
+		properties, ok := interfaces[iface]
+		if !ok || !strings.HasPrefix(string(path), prefix) {
+			continue
+		}
+		text, _ := properties["UUID"].Value().(string)
+		uuid, err := bluetooth.ParseUUID(text)
+		if err != nil {
+			continue
+		}
+		children = append(children, bluezObject{path: path, uuid: uuid})
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].path < children[j].path })
+
+	if len(uuids) == 0 {
+		return children, nil
+	}
+	result := make([]bluezObject, 0, len(uuids))
+	for _, uuid := range uuids {
+		found := false
+		for _, child := range children {
+			if child.uuid == uuid {
+				result = append(result, child)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s %s not found", kind, uuid.String())
+		}
+	}
+	return result, nil
+}
+
+// managedObjects returns all objects BlueZ exports on conn
+func managedObjects(conn *dbus.Conn) (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	err := conn.Object("org.bluez", "/").Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list BlueZ objects: %v", err)
+	}
+	return objects, nil
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package ble
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"tinygo.org/x/bluetooth"
+)
+
+// interfacesAdded returns the signal BlueZ sends for a new device at path
+func interfacesAdded(path dbus.ObjectPath, properties map[string]dbus.Variant) *dbus.Signal {
+	return &dbus.Signal{
+		Path: "/",
+		Name: "org.freedesktop.DBus.ObjectManager.InterfacesAdded",
+		Body: []interface{}{path, map[string]map[string]dbus.Variant{"org.bluez.Device1": properties}},
+	}
+}
+
+// propertiesChanged returns the signal BlueZ sends for changed Device1 properties at path
+func propertiesChanged(path dbus.ObjectPath, changes map[string]dbus.Variant) *dbus.Signal {
+	return &dbus.Signal{
+		Path: path,
+		Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+		Body: []interface{}{"org.bluez.Device1", changes, []string{}},
+	}
+}
+
+func TestBlueZScanOnlyReportsOwnDevices(t *testing.T) {
+	adapter, err := NewAdapterByID("hci1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := adapter.(*bluezAdapter)
+	devices := make(map[dbus.ObjectPath]map[string]dbus.Variant)
+
+	other := interfacesAdded("/org/bluez/hci0/dev_11_22_33_44_55_66", map[string]dbus.Variant{
+		"Address": dbus.MakeVariant("11:22:33:44:55:66"),
+	})
+	if _, ok := a.advertised(devices, other); ok {
+		t.Error("reported a device found by hci0")
+	}
+
+	path := dbus.ObjectPath("/org/bluez/hci1/dev_AA_BB_CC_DD_EE_FF")
+	properties, ok := a.advertised(devices, interfacesAdded(path, map[string]dbus.Variant{
+		"Address":          dbus.MakeVariant("AA:BB:CC:DD:EE:FF"),
+		"AddressType":      dbus.MakeVariant("random"),
+		"Name":             dbus.MakeVariant("Sensor"),
+		"RSSI":             dbus.MakeVariant(int16(-60)),
+		"UUIDs":            dbus.MakeVariant([]string{"0000180d-0000-1000-8000-00805f9b34fb"}),
+		"ManufacturerData": dbus.MakeVariant(map[uint16]dbus.Variant{0x004C: dbus.MakeVariant([]byte{1, 2})}),
+	}))
+	if !ok {
+		t.Fatal("new device of hci1 was not reported")
+	}
+	result, ok := bluezScanResult(properties)
+	if !ok {
+		t.Fatal("scan result could not be built")
+	}
+	if result.Address.String() != "AA:BB:CC:DD:EE:FF" || !result.Address.IsRandom() {
+		t.Errorf("got address %s (random %t), want random AA:BB:CC:DD:EE:FF", result.Address.String(), result.Address.IsRandom())
+	}
+	if result.LocalName() != "Sensor" || result.RSSI != -60 {
+		t.Errorf("got %q at %d dBm, want Sensor at -60 dBm", result.LocalName(), result.RSSI)
+	}
+	if !result.HasServiceUUID(bluetooth.New16BitUUID(0x180D)) {
+		t.Error("advertised service 0x180D is missing")
+	}
+	if data := result.ManufacturerData(); len(data) != 1 || data[0].CompanyID != 0x004C {
+		t.Errorf("got manufacturer data %v, want one element of company 0x004C", data)
+	}
+
+	if _, ok := a.advertised(devices, propertiesChanged(path, map[string]dbus.Variant{"Connected": dbus.MakeVariant(true)})); ok {
+		t.Error("a change of Connected was reported as an advertisement")
+	}
+	properties, ok = a.advertised(devices, propertiesChanged(path, map[string]dbus.Variant{"RSSI": dbus.MakeVariant(int16(-50))}))
+	if !ok {
+		t.Fatal("a new RSSI was not reported")
+	}
+	if result, _ := bluezScanResult(properties); result.RSSI != -50 || result.LocalName() != "Sensor" {
+		t.Errorf("got %q at %d dBm after the update, want Sensor at -50 dBm", result.LocalName(), result.RSSI)
+	}
+}
+
+func TestBlueZChildren(t *testing.T) {
+	gatt := func(iface, uuid string) map[string]map[string]dbus.Variant {
+		return map[string]map[string]dbus.Variant{iface: {"UUID": dbus.MakeVariant(uuid)}}
+	}
+	device := "/org/bluez/hci1/dev_AA_BB_CC_DD_EE_FF"
+	objects := map[dbus.ObjectPath]map[string]map[string]dbus.Variant{
+		dbus.ObjectPath(device + "/service0001"):          gatt("org.bluez.GattService1", "0000180f-0000-1000-8000-00805f9b34fb"),
+		dbus.ObjectPath(device + "/service0010"):          gatt("org.bluez.GattService1", "0000180d-0000-1000-8000-00805f9b34fb"),
+		dbus.ObjectPath(device + "/service0010/char0011"): gatt("org.bluez.GattCharacteristic1", "00002a37-0000-1000-8000-00805f9b34fb"),
+	}
+
+	heartRate, battery := bluetooth.New16BitUUID(0x180D), bluetooth.New16BitUUID(0x180F)
+	found, err := bluezChildren(objects, device+"/service", "org.bluez.GattService1", []bluetooth.UUID{heartRate, battery}, "service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].uuid != heartRate || found[1].uuid != battery {
+		t.Errorf("got %v, want the services in the requested order", found)
+	}
+
+	all, err := bluezChildren(objects, device+"/service", "org.bluez.GattService1", nil, "service")
+	if err != nil || len(all) != 2 {
+		t.Errorf("got %v, %v, want both services", all, err)
+	}
+
+	if _, err := bluezChildren(objects, device+"/service", "org.bluez.GattService1", []bluetooth.UUID{bluetooth.New16BitUUID(0x1800)}, "service"); err == nil {
+		t.Error("a missing service was not reported")
+	}
+}
+
+func TestNewAdapterByIDRejectsInvalidIDs(t *testing.T) {
+	for _, id := range []string{"", "hci0/dev_AA", "hci-1"} {
+		if _, err := NewAdapterByID(id); err == nil {
+			t.Errorf("NewAdapterByID(%q) succeeded", id)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package ble
+
+import "errors"
+
+// NewAdapterByID selects a BlueZ adapter and is only supported on Linux
+func NewAdapterByID(id string) (Adapter, error) {
+	return nil, errors.New("adapter selection is only supported on Linux")
+}
+
+// AdapterIDs lists the BlueZ adapters and is only supported on Linux
+func AdapterIDs() ([]string, error) {
+	return nil, errors.New("adapter selection is only supported on Linux")
+}
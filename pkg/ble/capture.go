@@ -0,0 +1,274 @@
+package ble
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Record is one value delivered by the manager: a notification, or the result
+// of a read by a ModeRead or ModePoll subscription
+type Record struct {
+	Time           time.Time
+	Device         string
+	Address        string
+	Service        bluetooth.UUID
+	Characteristic bluetooth.UUID
+	Data           []byte
+}
+
+// recordJSON is the capture file representation of a Record
+type recordJSON struct {
+	Time           time.Time `json:"time"`
+	Device         string    `json:"device"`
+	Address        string    `json:"address"`
+	Service        string    `json:"service"`
+	Characteristic string    `json:"characteristic"`
+	Data           string    `json:"data"` // hex
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recordJSON{
+		Time:           r.Time,
+		Device:         r.Device,
+		Address:        r.Address,
+		Service:        r.Service.String(),
+		Characteristic: r.Characteristic.String(),
+		Data:           hex.EncodeToString(r.Data),
+	})
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var raw recordJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	service, err := bluetooth.ParseUUID(raw.Service)
+	if err != nil {
+		return fmt.Errorf("invalid service UUID %q: %v", raw.Service, err)
+	}
+	characteristic, err := bluetooth.ParseUUID(raw.Characteristic)
+	if err != nil {
+		return fmt.Errorf("invalid characteristic UUID %q: %v", raw.Characteristic, err)
+	}
+	payload, err := hex.DecodeString(raw.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data %q: %v", raw.Data, err)
+	}
+
+	*r = Record{
+		Time:           raw.Time,
+		Device:         raw.Device,
+		Address:        raw.Address,
+		Service:        service,
+		Characteristic: characteristic,
+		Data:           payload,
+	}
+	return nil
+}
+
+// Recorder writes records as newline-delimited JSON
+type Recorder struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewRecorder creates a recorder writing to w, e.g. an *os.File
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// Write appends one record to the capture
+func (r *Recorder) Write(record Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.encoder.Encode(record)
+}
+
+// ReadCapture reads all records of a newline-delimited JSON capture
+func ReadCapture(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("capture line %d: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read capture: %v", err)
+	}
+	return records, nil
+}
+
+// Replay feeds records into the subscriptions of configs as if the devices
+// had sent them. Records are matched by device name, service and
+// characteristic. ModePoll subscriptions get a characteristic whose Read
+// returns the latest replayed value, so they only see values that stay current
+// for at least one poll interval. speed 1 keeps the original timing, 10
+// replays ten times faster and 0 without any delay. Replay returns when all
+// records are delivered or ctx is cancelled; handler errors are joined into
+// the returned error.
+func Replay(ctx context.Context, records []Record, configs []DeviceConfig, speed float64) error {
+	type target struct {
+		device       string
+		subscription Subscription
+		value        *replayedCharacteristic // ModePoll only
+	}
+
+	targets := make(map[string]map[characteristicKey]*target)
+	for _, config := range configs {
+		targets[config.Name] = make(map[characteristicKey]*target)
+		for _, sub := range config.subscriptions() {
+			t := &target{device: config.Name, subscription: sub}
+			if sub.Mode == ModePoll && sub.Reader != nil {
+				t.value = &replayedCharacteristic{uuid: sub.CharacteristicUUID}
+				sub.Reader(config.Name, t.value)
+				defer sub.Reader(config.Name, nil)
+			}
+			targets[config.Name][sub.key()] = t
+		}
+	}
+
+	var errs []error
+	for i, record := range records {
+		if i > 0 && speed > 0 {
+			delay := time.Duration(float64(record.Time.Sub(records[i-1].Time)) / speed)
+			if err := sleep(ctx, delay); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+		} else if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		t, ok := targets[record.Device][characteristicKey{record.Service, record.Characteristic}]
+		if !ok {
+			continue
+		}
+
+		if t.value != nil {
+			t.value.set(record.Data)
+			continue
+		}
+		if t.subscription.Handler != nil {
+			if err := t.subscription.Handler(t.device, record.Data); err != nil {
+				errs = append(errs, fmt.Errorf("record %d (%s): %w", i+1, t.device, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// replayedCharacteristic is the Characteristic handed to ModePoll readers during a replay
+type replayedCharacteristic struct {
+	uuid  bluetooth.UUID
+	value []byte
+	mu    sync.Mutex
+}
+
+func (c *replayedCharacteristic) set(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = append([]byte(nil), data...)
+}
+
+func (c *replayedCharacteristic) UUID() bluetooth.UUID {
+	return c.uuid
+}
+
+func (c *replayedCharacteristic) EnableNotifications(callback func(buf []byte)) error {
+	return errors.New("ble: notifications are not replayed")
+}
+
+func (c *replayedCharacteristic) Read(data []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copy(data, c.value), nil
+}
+
+func (c *replayedCharacteristic) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (c *replayedCharacteristic) WriteWithoutResponse(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// recordingCharacteristic records every value read through it
+type recordingCharacteristic struct {
+	Characteristic
+	record func(data []byte)
+}
+
+func (c recordingCharacteristic) Read(data []byte) (int, error) {
+	n, err := c.Characteristic.Read(data)
+	if err == nil {
+		c.record(data[:min(n, len(data))])
+	}
+	return n, err
+}
+
+// SetRecorder records every value received for subscriptions from now on,
+// stamped with its arrival time. Notifications are recorded before they are
+// queued, so responses consumed by Request and values dropped on overflow
+// are recorded too. Passing nil stops recording.
+func (m *SimpleManager) SetRecorder(recorder *Recorder) {
+	m.recorder.Store(recorder)
+}
+
+// record writes a value that has just been received to the recorder, if any
+func (m *SimpleManager) record(deviceName, address string, sub Subscription, data []byte) {
+	recorder := m.recorder.Load()
+	if recorder == nil {
+		return
+	}
+
+	err := recorder.Write(Record{
+		Time:           time.Now(),
+		Device:         deviceName,
+		Address:        address,
+		Service:        sub.ServiceUUID,
+		Characteristic: sub.CharacteristicUUID,
+		Data:           data,
+	})
+	if err != nil {
+		m.log().Warn("failed to record value", "device", deviceName, "phase", "notify", "error", err)
+	}
+}
+
+// SetRecorder records every value received for subscriptions, see SimpleManager.SetRecorder
+func (m *Manager) SetRecorder(recorder *Recorder) {
+	m.simpleManager.SetRecorder(recorder)
+}
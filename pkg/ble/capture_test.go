@@ -0,0 +1,270 @@
+package ble_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+var testPollUUID = bluetooth.New16BitUUID(0x2A19)
+
+// testRecords returns three records of a sensor, the second one of its polled characteristic
+func testRecords() []ble.Record {
+	start := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	record := func(offset time.Duration, characteristic bluetooth.UUID, data ...byte) ble.Record {
+		return ble.Record{
+			Time:           start.Add(offset),
+			Device:         "Sensor",
+			Address:        "11:22:33:44:55:66",
+			Service:        testServiceUUID,
+			Characteristic: characteristic,
+			Data:           data,
+		}
+	}
+	return []ble.Record{
+		record(0, testCharacteristicUUID, 0x01),
+		record(time.Second, testPollUUID, 0x64),
+		record(2*time.Second, testCharacteristicUUID, 0x02, 0x03),
+	}
+}
+
+func TestCaptureRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := ble.NewRecorder(&buf)
+	for _, record := range testRecords() {
+		if err := recorder.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := ble.ReadCapture(strings.NewReader(buf.String() + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testRecords()
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if !record.Time.Equal(want[i].Time) || record.Device != want[i].Device || record.Address != want[i].Address ||
+			record.Service != want[i].Service || record.Characteristic != want[i].Characteristic || !bytes.Equal(record.Data, want[i].Data) {
+			t.Errorf("record %d: got %+v, want %+v", i+1, record, want[i])
+		}
+	}
+}
+
+func TestReadCaptureReportsLine(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ble.NewRecorder(&buf).Write(testRecords()[0]); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString(`{"device": "Sensor", "service": "180d", "characteristic": "2a37", "data": "zz"}` + "\n")
+
+	_, err := ble.ReadCapture(&buf)
+	if err == nil || !strings.HasPrefix(err.Error(), "capture line 2:") {
+		t.Errorf("got %v, want an error for capture line 2", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var notified [][]byte
+	var polled []byte
+	var readers []ble.Characteristic
+	failed := errors.New("handler failed")
+
+	configs := []ble.DeviceConfig{{
+		Name:               "Sensor",
+		ServiceUUID:        testServiceUUID,
+		CharacteristicUUID: testCharacteristicUUID,
+		NotificationHandler: func(deviceName string, data []byte) error {
+			notified = append(notified, data)
+			if len(data) == 1 {
+				return nil
+			}
+			// By now the polled value has been replayed
+			buf := make([]byte, 4)
+			n, _ := readers[0].Read(buf)
+			polled = buf[:n]
+			return failed
+		},
+		Subscriptions: []ble.Subscription{{
+			ServiceUUID:        testServiceUUID,
+			CharacteristicUUID: testPollUUID,
+			Mode:               ble.ModePoll,
+			Reader: func(deviceName string, characteristic ble.Characteristic) {
+				readers = append(readers, characteristic)
+			},
+		}},
+	}}
+
+	records := append(testRecords(), ble.Record{Device: "Other", Service: testServiceUUID, Characteristic: testCharacteristicUUID})
+	err := ble.Replay(context.Background(), records, configs, 0)
+	if !errors.Is(err, failed) || !strings.Contains(err.Error(), "record 3 (Sensor)") {
+		t.Errorf("got %v, want the handler error of record 3", err)
+	}
+	if len(notified) != 2 || !bytes.Equal(notified[0], []byte{0x01}) || !bytes.Equal(notified[1], []byte{0x02, 0x03}) {
+		t.Errorf("got notifications %x, want 01 and 0203", notified)
+	}
+	if !bytes.Equal(polled, []byte{0x64}) {
+		t.Errorf("poll read %x, want 64", polled)
+	}
+	if len(readers) != 2 || readers[1] != nil {
+		t.Errorf("reader got %d characteristics, want the replayed one and nil", len(readers))
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var received int
+	configs := []ble.DeviceConfig{{
+		Name:               "Sensor",
+		ServiceUUID:        testServiceUUID,
+		CharacteristicUUID: testCharacteristicUUID,
+		NotificationHandler: func(deviceName string, data []byte) error {
+			received++
+			cancel()
+			return nil
+		},
+	}}
+
+	// At the original speed, the second record is a second away
+	err := ble.Replay(ctx, testRecords(), configs, 1)
+	if !errors.Is(err, context.Canceled) || received != 1 {
+		t.Errorf("got %v after %d records, want context.Canceled after 1", err, received)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestManagerRecordsNotifications(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	var capture syncBuffer
+	m.SetRecorder(ble.NewRecorder(&capture))
+
+	received := make(chan []byte, 1)
+	connectAll(t, m, testConfig("Sensor", received))
+	if err := characteristic.Notify([]byte{0x2A}); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, received)
+
+	records, err := ble.ReadCapture(strings.NewReader(capture.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	record := records[0]
+	if record.Device != "Sensor" || record.Address != peripheral.Address.String() ||
+		record.Characteristic != testCharacteristicUUID || !bytes.Equal(record.Data, []byte{0x2A}) {
+		t.Errorf("got record %+v, want the notification of Sensor", record)
+	}
+}
+
+func TestManagerRecordsOnArrival(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	_, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	var capture syncBuffer
+	m.SetRecorder(ble.NewRecorder(&capture))
+
+	// The handler is still busy with the first value when the second arrives
+	release := make(chan struct{})
+	handled := make(chan []byte, 2)
+	config := testConfig("Sensor", nil)
+	config.NotificationHandler = func(deviceName string, data []byte) error {
+		<-release
+		handled <- data
+		return nil
+	}
+	connectAll(t, m, config)
+
+	before := time.Now()
+	for _, value := range []byte{1, 2} {
+		if err := characteristic.Notify([]byte{value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after := time.Now()
+
+	records, err := ble.ReadCapture(strings.NewReader(capture.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records before the handler returned, want 2", len(records))
+	}
+	for _, record := range records {
+		if record.Time.Before(before) || record.Time.After(after) {
+			t.Errorf("record of %v stamped %v, want its arrival between %v and %v", record.Data, record.Time, before, after)
+		}
+	}
+
+	close(release)
+	receive(t, handled)
+	receive(t, handled)
+}
+
+func TestManagerRecordsResponses(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	_, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+	characteristic.OnWrite(func(data []byte) {
+		characteristic.Notify(append([]byte{0x80}, data...))
+	})
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	var capture syncBuffer
+	m.SetRecorder(ble.NewRecorder(&capture))
+
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+	response, err := m.Request(context.Background(), "Sensor", ble.Request{
+		ServiceUUID:                testServiceUUID,
+		CharacteristicUUID:         testCharacteristicUUID,
+		Data:                       []byte{0x01},
+		ResponseCharacteristicUUID: testCharacteristicUUID,
+		Timeout:                    testTimeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ble.ReadCapture(strings.NewReader(capture.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !bytes.Equal(records[0].Data, response) {
+		t.Errorf("got records %+v, want the response %v", records, response)
+	}
+}
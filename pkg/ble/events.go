@@ -0,0 +1,135 @@
+package ble
+
+import (
+	"errors"
+	"time"
+)
+
+// Disconnect reasons reported in Disconnected events and to the disconnect handler
+var (
+	// ErrConnectionLost means the link dropped without being asked to
+	ErrConnectionLost = errors.New("ble: connection lost")
+	// ErrDisconnectRequested means Disconnect was called for the device
+	ErrDisconnectRequested = errors.New("ble: disconnect requested")
+	// ErrManagerClosed means the manager was closed
+	ErrManagerClosed = errors.New("ble: manager closed")
+
+	// ErrWatchdogLost is wrapped into ErrConnectionLost when the Linux
+	// watchdog loses its D-Bus connection and can no longer supervise a device
+	ErrWatchdogLost = errors.New("ble: watchdog lost its D-Bus connection")
+)
+
+// DefaultEventBuffer is the channel capacity used by Subscribe when buffer <= 0
+const DefaultEventBuffer = 64
+
+// EventType identifies a connection lifecycle event
+type EventType int
+
+const (
+	// EventScanStarted is emitted when the manager starts scanning
+	EventScanStarted EventType = iota
+	// EventDeviceFound is emitted when a configured device advertises (RSSI is set)
+	EventDeviceFound
+	// EventConnecting is emitted before connecting to a found device
+	EventConnecting
+	// EventServicesDiscovered is emitted once the required service and characteristic are found
+	EventServicesDiscovered
+	// EventConnected is emitted when a device is connected and notifications are enabled
+	EventConnected
+	// EventNotificationDropped is emitted when a notification is lost because the device channel is full
+	EventNotificationDropped
+	// EventDisconnected is emitted when a connected device goes away (Err holds the reason)
+	EventDisconnected
+	// EventReconnectAttempt is emitted before every reconnect attempt (Attempt is set)
+	EventReconnectAttempt
+	// EventReconnectGaveUp is emitted when the reconnect policy is exhausted (Err holds the last error)
+	EventReconnectGaveUp
+)
+
+var eventTypeNames = map[EventType]string{
+	EventScanStarted:         "ScanStarted",
+	EventDeviceFound:         "DeviceFound",
+	EventConnecting:          "Connecting",
+	EventServicesDiscovered:  "ServicesDiscovered",
+	EventConnected:           "Connected",
+	EventNotificationDropped: "NotificationDropped",
+	EventDisconnected:        "Disconnected",
+	EventReconnectAttempt:    "ReconnectAttempt",
+	EventReconnectGaveUp:     "ReconnectGaveUp",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// Event is a connection lifecycle event emitted by SimpleManager
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Device  string // logical device name, empty for EventScanStarted
+	Address string // empty until the device has been found
+	RSSI    int16  // set for EventDeviceFound
+	Attempt int    // set for EventReconnectAttempt and EventReconnectGaveUp
+	Err     error  // set for EventDisconnected and EventReconnectGaveUp, compare with errors.Is
+}
+
+// Subscribe returns a channel receiving every lifecycle event of the manager
+// and a function that ends the subscription and closes the channel. Events are
+// never allowed to block the manager: when the channel buffer is full, further
+// events are dropped for this subscriber. The channel is closed by Close.
+func (m *SimpleManager) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+	ch := make(chan Event, buffer)
+
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+
+	if m.subscribers == nil {
+		// Manager already closed
+		close(ch)
+		return ch, func() {}
+	}
+	m.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		m.eventsMu.Lock()
+		defer m.eventsMu.Unlock()
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// emit delivers event to all subscribers without blocking
+func (m *SimpleManager) emit(event Event) {
+	event.Time = time.Now()
+	m.metrics.observe(event)
+	m.health.observe(event)
+
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// closeSubscribers closes all subscriber channels; later subscriptions receive a closed channel
+func (m *SimpleManager) closeSubscribers() {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+
+	for ch := range m.subscribers {
+		close(ch)
+	}
+	m.subscribers = nil
+}
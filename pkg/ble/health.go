@@ -0,0 +1,354 @@
+package ble
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Liveness probe defaults
+const (
+	DefaultProbeInterval = 30 * time.Second
+	DefaultProbeTimeout  = 5 * time.Second
+	DefaultProbeFailures = 2
+)
+
+// History lengths kept per device for Health
+const (
+	rssiHistory      = 30
+	reconnectHistory = 10
+)
+
+// errProbeTimeout is reported when a liveness probe read does not return in time
+var errProbeTimeout = errors.New("ble: liveness probe timed out")
+
+// HealthConfig enables connection-quality monitoring of a device
+type HealthConfig struct {
+	StaleAfter   time.Duration  // Health reports Stale once no value arrived for this long (0 disables)
+	RSSIInterval time.Duration  // how often to sample RSSI while connected (0 disables)
+	Probe        *LivenessProbe // nil disables the liveness probe
+}
+
+// LivenessProbe reads a characteristic periodically to detect links the
+// platform still reports as connected although the peripheral stopped
+// answering. After Failures consecutive failed reads the device is
+// disconnected and handled like a lost connection, including reconnects.
+type LivenessProbe struct {
+	ServiceUUID        bluetooth.UUID
+	CharacteristicUUID bluetooth.UUID
+	Interval           time.Duration // DefaultProbeInterval if zero
+	Timeout            time.Duration // DefaultProbeTimeout if zero
+	Failures           int           // DefaultProbeFailures if zero
+}
+
+// RSSISample is one signal strength measurement
+type RSSISample struct {
+	Time time.Time
+	RSSI int16
+}
+
+// Reconnect records one disconnect of a device and how it was recovered
+type Reconnect struct {
+	DisconnectedAt time.Time
+	Reason         error     // ErrConnectionLost, ErrDisconnectRequested or ErrManagerClosed
+	Attempts       int       // reconnect attempts so far
+	ReconnectedAt  time.Time // zero while reconnecting, or if the device did not come back
+}
+
+// Health describes the connection quality of a device
+type Health struct {
+	Device         string
+	Connected      bool
+	ConnectedAt    time.Time     // start of the current connection
+	Uptime         time.Duration // zero while disconnected
+	LastData       time.Time     // last notification or read value, zero if none yet
+	Stale          bool          // connected, but no value for HealthConfig.StaleAfter
+	RSSI           []RSSISample  // oldest first
+	Reconnects     []Reconnect   // oldest first
+	ProbeFailures  int           // consecutive failed liveness probes
+	LastProbeError error
+}
+
+// RSSITrend returns the change of the signal strength in dBm per minute,
+// fitted over the RSSI samples. It is 0 with fewer than two samples.
+func (h Health) RSSITrend() float64 {
+	if len(h.RSSI) < 2 {
+		return 0
+	}
+
+	// Least-squares slope with times relative to the first sample
+	var sumT, sumR, sumTT, sumTR float64
+	for _, sample := range h.RSSI {
+		t := sample.Time.Sub(h.RSSI[0].Time).Minutes()
+		r := float64(sample.RSSI)
+		sumT += t
+		sumR += r
+		sumTT += t * t
+		sumTR += t * r
+	}
+	n := float64(len(h.RSSI))
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumTR - sumT*sumR) / denominator
+}
+
+// healthTracker keeps the state reported by Health per logical device name
+type healthTracker struct {
+	mu      sync.Mutex
+	devices map[string]*deviceHealth
+}
+
+type deviceHealth struct {
+	staleAfter     time.Duration
+	connected      bool
+	connectedAt    time.Time
+	lastData       time.Time
+	rssi           []RSSISample
+	reconnects     []Reconnect
+	probeFailures  int
+	lastProbeError error
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{devices: make(map[string]*deviceHealth)}
+}
+
+// update applies fn to the health of a device, creating it on first use
+func (t *healthTracker) update(name string, fn func(h *deviceHealth)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.devices[name]
+	if !ok {
+		h = &deviceHealth{}
+		t.devices[name] = h
+	}
+	fn(h)
+}
+
+// observe derives connection state, RSSI and reconnect history from a lifecycle event
+func (t *healthTracker) observe(event Event) {
+	if event.Device == "" {
+		return
+	}
+
+	t.update(event.Device, func(h *deviceHealth) {
+		switch event.Type {
+		case EventDeviceFound:
+			h.addRSSI(event.Time, event.RSSI)
+		case EventConnected:
+			h.connected = true
+			h.connectedAt = event.Time
+			h.probeFailures = 0
+			h.lastProbeError = nil
+			if n := len(h.reconnects); n > 0 && h.reconnects[n-1].ReconnectedAt.IsZero() {
+				h.reconnects[n-1].ReconnectedAt = event.Time
+			}
+		case EventDisconnected:
+			h.connected = false
+			h.reconnects = append(h.reconnects, Reconnect{DisconnectedAt: event.Time, Reason: event.Err})
+			if len(h.reconnects) > reconnectHistory {
+				h.reconnects = h.reconnects[1:]
+			}
+		case EventReconnectAttempt:
+			if n := len(h.reconnects); n > 0 && h.reconnects[n-1].ReconnectedAt.IsZero() {
+				h.reconnects[n-1].Attempts = event.Attempt
+			}
+		}
+	})
+}
+
+func (h *deviceHealth) addRSSI(at time.Time, rssi int16) {
+	h.rssi = append(h.rssi, RSSISample{Time: at, RSSI: rssi})
+	if len(h.rssi) > rssiHistory {
+		h.rssi = h.rssi[1:]
+	}
+}
+
+// dataReceived records that a value of the device arrived
+func (t *healthTracker) dataReceived(name string) {
+	now := time.Now()
+	t.update(name, func(h *deviceHealth) {
+		h.lastData = now
+	})
+}
+
+// Health returns the connection quality of a device, or false if the manager
+// has never seen it
+func (m *SimpleManager) Health(deviceName string) (Health, bool) {
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+
+	h, ok := m.health.devices[deviceName]
+	if !ok {
+		return Health{}, false
+	}
+
+	now := time.Now()
+	result := Health{
+		Device:         deviceName,
+		Connected:      h.connected,
+		LastData:       h.lastData,
+		RSSI:           append([]RSSISample(nil), h.rssi...),
+		Reconnects:     append([]Reconnect(nil), h.reconnects...),
+		ProbeFailures:  h.probeFailures,
+		LastProbeError: h.lastProbeError,
+	}
+	if h.connected {
+		result.ConnectedAt = h.connectedAt
+		result.Uptime = now.Sub(h.connectedAt)
+
+		if h.staleAfter > 0 {
+			since := h.connectedAt
+			if h.lastData.After(since) {
+				since = h.lastData
+			}
+			result.Stale = now.Sub(since) > h.staleAfter
+		}
+	}
+	return result, true
+}
+
+// Health returns the connection quality of a device, see SimpleManager.Health
+func (m *Manager) Health(deviceName string) (Health, bool) {
+	return m.simpleManager.Health(deviceName)
+}
+
+// monitorHealth starts RSSI sampling and the liveness probe of a newly connected device
+func (m *SimpleManager) monitorHealth(device *SimpleDevice, config HealthConfig) {
+	m.health.update(device.Name, func(h *deviceHealth) {
+		h.staleAfter = config.StaleAfter
+	})
+
+	if config.RSSIInterval > 0 {
+		if reader, ok := device.Connection.(RSSIReader); ok {
+			go m.sampleRSSI(device, reader, config.RSSIInterval)
+		} else {
+			m.log().Debug("connection cannot report RSSI, sampling disabled", "device", device.Name, "phase", "health")
+		}
+	}
+	if config.Probe != nil {
+		go m.probe(device, *config.Probe)
+	}
+}
+
+// sampleRSSI reads the signal strength of a connected device every interval
+func (m *SimpleManager) sampleRSSI(device *SimpleDevice, reader RSSIReader, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-device.done:
+			return
+		case <-ticker.C:
+		}
+
+		rssi, err := reader.RSSI()
+		if err != nil {
+			m.log().Debug("RSSI unavailable", "device", device.Name, "phase", "health", "error", err)
+			continue
+		}
+		m.health.update(device.Name, func(h *deviceHealth) {
+			h.addRSSI(time.Now(), rssi)
+		})
+		m.metrics.setRSSI(device.Name, rssi)
+	}
+}
+
+// probe reads the probe characteristic every interval and drops the
+// connection after too many consecutive failures
+func (m *SimpleManager) probe(device *SimpleDevice, probe LivenessProbe) {
+	interval, timeout, failures := probe.Interval, probe.Timeout, probe.Failures
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	if failures <= 0 {
+		failures = DefaultProbeFailures
+	}
+
+	log := m.log().With("device", device.Name, "phase", "probe", "characteristic", probe.CharacteristicUUID.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// A read that timed out may still be pending; it counts as failed until it returns
+	var pending chan error
+	for failed := 0; ; {
+		select {
+		case <-device.done:
+			return
+		case <-ticker.C:
+		}
+
+		if pending == nil {
+			pending = make(chan error, 1)
+			go func(result chan<- error) {
+				result <- m.probeRead(device, probe)
+			}(pending)
+		}
+
+		var err error
+		select {
+		case err = <-pending:
+			pending = nil
+		case <-time.After(timeout):
+			err = errProbeTimeout
+		case <-device.done:
+			return
+		}
+
+		if err == nil {
+			failed = 0
+		} else {
+			failed++
+			log.Warn("liveness probe failed", "failures", failed, "error", err)
+		}
+		m.health.update(device.Name, func(h *deviceHealth) {
+			h.probeFailures = failed
+			h.lastProbeError = err
+		})
+
+		if failed >= failures {
+			log.Warn("link unresponsive, dropping connection", "failures", failed)
+			m.dropConnection(device, err)
+			return
+		}
+	}
+}
+
+// probeRead reads the probe characteristic once
+func (m *SimpleManager) probeRead(device *SimpleDevice, probe LivenessProbe) error {
+	char, err := device.characteristic(characteristicKey{probe.ServiceUUID, probe.CharacteristicUUID})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, maxValueSize)
+	if _, err := char.Read(buf); err != nil {
+		return fmt.Errorf("read failed: %v", err)
+	}
+	return nil
+}
+
+// dropConnection disconnects a device that is still reported as connected and
+// handles it like a lost connection caused by cause
+func (m *SimpleManager) dropConnection(device *SimpleDevice, cause error) {
+	m.mu.RLock()
+	current := m.connected[device.Name] == device
+	m.mu.RUnlock()
+	if !current {
+		return
+	}
+
+	// Handled before the link goes down so the cause is reported; the adapter's
+	// own report of the disconnect is ignored as the address is no longer known.
+	m.handleDisconnect(device.Address, fmt.Errorf("%w: %v", ErrConnectionLost, cause))
+	device.Connection.Disconnect()
+}
@@ -0,0 +1,162 @@
+package ble_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+)
+
+// awaitHealth polls the health of deviceName until ok accepts it
+func awaitHealth(t *testing.T, m *ble.SimpleManager, deviceName, what string, ok func(ble.Health) bool) ble.Health {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for {
+		health, found := m.Health(deviceName)
+		if found && ok(health) {
+			return health
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: got health %+v after %s", what, health, testTimeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHealthProbeDropsUnresponsiveLink(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	config := testConfig("Sensor", make(chan []byte, 1))
+	config.Health.Probe = &ble.LivenessProbe{
+		ServiceUUID:        testServiceUUID,
+		CharacteristicUUID: testCharacteristicUUID,
+		Interval:           20 * time.Millisecond,
+		Timeout:            testTimeout,
+		Failures:           2,
+	}
+	connectAll(t, m, config)
+
+	// A responsive link passes its probes
+	time.Sleep(5 * config.Health.Probe.Interval)
+	if health, _ := m.Health("Sensor"); !health.Connected || health.ProbeFailures != 0 || health.LastProbeError != nil {
+		t.Fatalf("got health %+v, want a connected device without probe failures", health)
+	}
+
+	peripheral.SetUnresponsive(true)
+	event := nextEvent(t, events, ble.EventDisconnected)
+	if !errors.Is(event.Err, ble.ErrConnectionLost) || !strings.Contains(event.Err.Error(), "not responding") {
+		t.Errorf("got reason %v, want ErrConnectionLost with the probe error", event.Err)
+	}
+	health, _ := m.Health("Sensor")
+	if health.ProbeFailures != 2 || health.LastProbeError == nil {
+		t.Errorf("got %d probe failures (last %v), want 2", health.ProbeFailures, health.LastProbeError)
+	}
+
+	peripheral.SetUnresponsive(false)
+	nextEvent(t, events, ble.EventConnected)
+	health = awaitHealth(t, m, "Sensor", "after the reconnect", func(h ble.Health) bool { return h.Connected })
+	if health.ProbeFailures != 0 || health.LastProbeError != nil {
+		t.Errorf("got %d probe failures (last %v) after the reconnect, want none", health.ProbeFailures, health.LastProbeError)
+	}
+}
+
+func TestHealthSamplesRSSI(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+	peripheral.SetRSSI(-70)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	config := testConfig("Sensor", make(chan []byte, 1))
+	config.Health.RSSIInterval = 5 * time.Millisecond
+	connectAll(t, m, config)
+
+	// The scan result gives the first sample, the connection the others
+	peripheral.SetRSSI(-50)
+	health := awaitHealth(t, m, "Sensor", "RSSI sampling", func(h ble.Health) bool {
+		return len(h.RSSI) >= 3 && h.RSSI[len(h.RSSI)-1].RSSI == -50
+	})
+	if health.RSSI[0].RSSI != -70 {
+		t.Errorf("got first sample %d dBm, want -70 dBm from the scan", health.RSSI[0].RSSI)
+	}
+	for i := 1; i < len(health.RSSI); i++ {
+		if health.RSSI[i].Time.Before(health.RSSI[i-1].Time) {
+			t.Fatalf("samples are not oldest first: %v", health.RSSI)
+		}
+	}
+	if trend := health.RSSITrend(); trend <= 0 {
+		t.Errorf("got trend %.1f dBm/min, want a rising signal", trend)
+	}
+}
+
+func TestHealthStale(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	_, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	received := make(chan []byte, 1)
+	config := testConfig("Sensor", received)
+	config.Health.StaleAfter = 50 * time.Millisecond
+	connectAll(t, m, config)
+
+	if health, _ := m.Health("Sensor"); health.Stale {
+		t.Error("a new connection is stale")
+	}
+	awaitHealth(t, m, "Sensor", "without values", func(h ble.Health) bool { return h.Stale })
+
+	if err := characteristic.Notify([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, received)
+	health, _ := m.Health("Sensor")
+	if health.Stale || health.LastData.IsZero() {
+		t.Errorf("got stale %t with last data at %s, want fresh after a notification", health.Stale, health.LastData)
+	}
+}
+
+func TestHealthReconnects(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+
+	// The first attempt fails, the second succeeds
+	peripheral.SetConnectError(errors.New("busy"))
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventDisconnected)
+	for nextEvent(t, events, ble.EventReconnectAttempt).Attempt < 2 {
+	}
+	peripheral.SetConnectError(nil)
+	nextEvent(t, events, ble.EventConnected)
+
+	health := awaitHealth(t, m, "Sensor", "after the reconnect", func(h ble.Health) bool { return h.Connected })
+	if len(health.Reconnects) != 1 {
+		t.Fatalf("got reconnects %+v, want one", health.Reconnects)
+	}
+	reconnect := health.Reconnects[0]
+	if !errors.Is(reconnect.Reason, ble.ErrConnectionLost) {
+		t.Errorf("got reason %v, want ErrConnectionLost", reconnect.Reason)
+	}
+	if reconnect.Attempts < 2 {
+		t.Errorf("got %d attempts, want at least 2", reconnect.Attempts)
+	}
+	if reconnect.ReconnectedAt.Before(reconnect.DisconnectedAt) || !health.ConnectedAt.Equal(reconnect.ReconnectedAt) {
+		t.Errorf("reconnected at %s after a disconnect at %s, connected since %s", reconnect.ReconnectedAt, reconnect.DisconnectedAt, health.ConnectedAt)
+	}
+}
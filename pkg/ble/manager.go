@@ -4,61 +4,152 @@ package ble
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/internal/logging"
 	"tinygo.org/x/bluetooth"
 )
 
+// DefaultScanTimeout is how long a scan looks for a device before giving up
+const DefaultScanTimeout = 30 * time.Second
+
+// DefaultReconnectDelay is the pause between reconnect attempts of the default ReconnectPolicy
+const DefaultReconnectDelay = 3 * time.Second
+
 // SimpleManager handles BLE device connections with automatic reconnect support
 type SimpleManager struct {
-	adapter           *bluetooth.Adapter
+	adapter           Adapter
 	connected         map[string]*SimpleDevice
 	addressToName     map[string]string
-	pendingConfigs    map[string]DeviceConfig
+	pendingConfigs    map[string]pendingDevice
 	disconnectHandler func(deviceName string, address string, err error)
 	reconnectHandler  func(deviceName string, address string)
 	mu                sync.RWMutex
 	enabled           bool
+	enabling          *enableAttempt
+	registry          *Registry
 	closing           bool
+	ctx               context.Context
+	cancel            context.CancelFunc
+	logger            atomic.Pointer[slog.Logger]
+	recorder          atomic.Pointer[Recorder]
+	metrics           *metrics
+	health            *healthTracker
+	subscribers       map[chan Event]struct{}
+	eventsMu          sync.RWMutex
+	scanSlot          chan struct{} // held from starting a scan until its Scan call returns
+}
+
+// pendingDevice is a device the manager keeps connected. Its lifetime is
+// independent of the context of the connect call and ends with Disconnect,
+// Close or when the reconnect policy gives up.
+type pendingDevice struct {
+	config DeviceConfig
+	ctx    context.Context // bounds the reconnects of the device
+	cancel context.CancelFunc
+}
+
+// enableAttempt tracks an in-flight adapter initialization
+type enableAttempt struct {
+	done chan struct{}
+	err  error
 }
 
 // SimpleDevice represents a connected BLE device
 type SimpleDevice struct {
 	Name            string
 	Address         bluetooth.Address
-	Device          *bluetooth.Device
-	Channel         <-chan []byte
-	rawChannel      chan []byte
+	Connection      Connection
+	Device          *bluetooth.Device // nil unless backed by the tinygo adapter
+	AdapterID       string            // adapter of an AdapterPool the device is connected through
+	Channel         <-chan []byte     // values of the first notify/indicate subscription, nil if none
+	rawChannels     []chan []byte
+	done            chan struct{}
 	disconnectFunc  func()
-	cancelWatchdog  func()
+	reason          error // why the connection ended, set before done is closed
 	closeOnce       sync.Once
+	characteristics map[characteristicKey]Characteristic // discovered so far, guarded by mu
+	responses       map[characteristicKey]*responseWaiters
+	mu              sync.Mutex
 }
 
-func (d *SimpleDevice) closeChannel() {
+// closeChannel closes all subscription channels and stops polling
+func (d *SimpleDevice) closeChannel(reason error) {
 	d.closeOnce.Do(func() {
-		close(d.rawChannel)
+		d.reason = reason
+		close(d.done)
+		for _, ch := range d.rawChannels {
+			close(ch)
+		}
 	})
 }
 
-// NewSimpleManager creates a new simplified BLE manager
+// NewSimpleManager creates a new simplified BLE manager on the default adapter
 func NewSimpleManager() *SimpleManager {
-	return &SimpleManager{
-		adapter:        bluetooth.DefaultAdapter,
+	return NewSimpleManagerWithAdapter(NewTinyGoAdapter(bluetooth.DefaultAdapter))
+}
+
+// NewSimpleManagerWithAdapter creates a new simplified BLE manager on the given adapter
+func NewSimpleManagerWithAdapter(adapter Adapter) *SimpleManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &SimpleManager{
+		adapter:        adapter,
 		connected:      make(map[string]*SimpleDevice),
 		addressToName:  make(map[string]string),
-		pendingConfigs: make(map[string]DeviceConfig),
+		pendingConfigs: make(map[string]pendingDevice),
+		subscribers:    make(map[chan Event]struct{}),
+		metrics:        newMetrics(),
+		health:         newHealthTracker(),
+		scanSlot:       make(chan struct{}, 1),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	m.logger.Store(logging.Discard())
+	return m
+}
+
+// SetLogger sets the logger for connection lifecycle messages. Records carry
+// the attributes device, address, rssi and phase where applicable. Passing nil
+// restores the default, which discards everything. If the adapter has a
+// SetLogger(*slog.Logger) method, it receives the logger as well.
+func (m *SimpleManager) SetLogger(logger *slog.Logger) {
+	logger = logging.OrDiscard(logger)
+	m.logger.Store(logger)
+
+	if adapter, ok := m.adapter.(interface{ SetLogger(*slog.Logger) }); ok {
+		adapter.SetLogger(logger)
 	}
 }
 
-// SetDisconnectHandler sets the callback for device disconnections
+// log returns the current logger
+func (m *SimpleManager) log() *slog.Logger {
+	return m.logger.Load()
+}
+
+// SetDisconnectHandler sets the callback for unexpected device disconnections.
+// err is ErrConnectionLost, wrapping the cause if the adapter reports one, e.g.
+// ErrWatchdogLost, so compare it with errors.Is. Subscribe also reports
+// requested disconnects.
 func (m *SimpleManager) SetDisconnectHandler(handler func(deviceName string, address string, err error)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.disconnectHandler = handler
 }
 
+// SetRegistry sets the registry used to bind devices to their address on the
+// first connect and to connect bound devices directly afterwards
+func (m *SimpleManager) SetRegistry(registry *Registry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.registry = registry
+}
+
 // SetReconnectHandler sets the callback for successful device reconnections
 func (m *SimpleManager) SetReconnectHandler(handler func(deviceName string, address string)) {
 	m.mu.Lock()
@@ -67,41 +158,74 @@ func (m *SimpleManager) SetReconnectHandler(handler func(deviceName string, addr
 }
 
 // enable initializes the BLE adapter and registers the connect/disconnect handler.
-// Must be called before any Connect calls.
-func (m *SimpleManager) enable() error {
+// Must be called before any Connect calls. If ctx is cancelled first, enable
+// returns early and the initialization finishes in the background.
+func (m *SimpleManager) enable(ctx context.Context) error {
 	m.mu.Lock()
 	if m.enabled {
 		m.mu.Unlock()
 		return nil
 	}
+	attempt := m.enabling
+	if attempt == nil {
+		attempt = &enableAttempt{done: make(chan struct{})}
+		m.enabling = attempt
+		go m.enableAdapter(attempt)
+	}
 	m.mu.Unlock()
 
-	fmt.Println("🔌 Enabling BLE adapter...")
+	select {
+	case <-attempt.done:
+		return attempt.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enableAdapter performs one adapter initialization and records its outcome
+func (m *SimpleManager) enableAdapter(attempt *enableAttempt) {
+	defer close(attempt.done)
+
+	m.log().Info("enabling BLE adapter", "phase", "enable")
 	if err := m.adapter.Enable(); err != nil {
-		return fmt.Errorf("failed to enable adapter: %v", err)
+		attempt.err = fmt.Errorf("failed to enable adapter: %v", err)
+
+		// Allow the next call to try again
+		m.mu.Lock()
+		m.enabling = nil
+		m.mu.Unlock()
+		return
 	}
 
-	// Give macOS time to initialize
-	time.Sleep(2 * time.Second)
-	fmt.Println("✅ BLE adapter enabled")
+	m.log().Info("BLE adapter enabled", "phase", "enable")
 
 	// Must be set before adapter.Connect() calls per tinygo/bluetooth docs
-	m.adapter.SetConnectHandler(func(device bluetooth.Device, connected bool) {
+	m.adapter.SetConnectHandler(func(address bluetooth.Address, connected bool) {
 		if !connected {
-			m.handleDisconnect(device)
+			m.handleDisconnect(address, nil)
 		}
 	})
+	if reporter, ok := m.adapter.(DisconnectReporter); ok {
+		reporter.SetDisconnectHandler(m.handleDisconnect)
+	}
 
 	m.mu.Lock()
 	m.enabled = true
+	m.enabling = nil
 	m.mu.Unlock()
-
-	return nil
 }
 
 // handleDisconnect is called by the adapter when a peripheral disconnects.
-func (m *SimpleManager) handleDisconnect(device bluetooth.Device) {
-	addrStr := device.Address.String()
+// cause is nil for an ordinary link loss and is wrapped into ErrConnectionLost
+// otherwise, unless it already wraps ErrConnectionLost.
+func (m *SimpleManager) handleDisconnect(address bluetooth.Address, cause error) {
+	addrStr := address.String()
+	reason := ErrConnectionLost
+	if errors.Is(cause, ErrConnectionLost) {
+		reason = cause
+	} else if cause != nil {
+		reason = fmt.Errorf("%w: %w", ErrConnectionLost, cause)
+	}
 
 	m.mu.Lock()
 	name, ok := m.addressToName[addrStr]
@@ -111,53 +235,73 @@ func (m *SimpleManager) handleDisconnect(device bluetooth.Device) {
 	}
 
 	simpleDevice := m.connected[name]
-	config, hasConfig := m.pendingConfigs[name]
+	pending, hasConfig := m.pendingConfigs[name]
 	isClosing := m.closing
+	disconnectHandler := m.disconnectHandler
 
 	delete(m.connected, name)
 	delete(m.addressToName, addrStr)
 	m.mu.Unlock()
 
-	// Stop the watchdog and close the channel to unblock the handleNotifications goroutine
+	// Close the channel to unblock the handleNotifications goroutine
 	if simpleDevice != nil {
-		if simpleDevice.cancelWatchdog != nil {
-			simpleDevice.cancelWatchdog()
-		}
-		simpleDevice.closeChannel()
+		simpleDevice.closeChannel(reason)
 	}
 
-	fmt.Printf("⚠️  Device %s [%s] disconnected\n", name, addrStr)
+	log := m.log().With("device", name, "address", addrStr)
+	if cause != nil {
+		log.Warn("device disconnected", "phase", "disconnect", "error", cause)
+	} else {
+		log.Warn("device disconnected", "phase", "disconnect")
+	}
+	m.emit(Event{Type: EventDisconnected, Device: name, Address: addrStr, Err: reason})
 
-	if m.disconnectHandler != nil {
-		m.disconnectHandler(name, addrStr, nil)
+	if disconnectHandler != nil {
+		disconnectHandler(name, addrStr, reason)
 	}
 
 	if hasConfig && !isClosing {
-		fmt.Printf("🔄 Will attempt to reconnect to %s...\n", name)
-		go m.reconnectLoop(config)
+		log.Info("scheduling reconnect", "phase", "reconnect")
+		go m.reconnectLoop(pending)
 	}
 }
 
-// reconnectLoop continuously attempts to reconnect until successful or closing.
-func (m *SimpleManager) reconnectLoop(config DeviceConfig) {
-	for {
-		m.mu.RLock()
-		isClosing := m.closing
-		m.mu.RUnlock()
+// reconnectLoop attempts to reconnect according to the device's ReconnectPolicy
+// until successful, the policy gives up, the device is disconnected or the
+// manager is closed.
+func (m *SimpleManager) reconnectLoop(pending pendingDevice) {
+	config := pending.config
+	policy := config.ReconnectPolicy
+	ctx := pending.ctx
 
-		if isClosing {
+	log := m.log().With("device", config.Name, "phase", "reconnect")
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		delay := policy.Delay(attempt)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info("stopped reconnecting", "reason", ctx.Err())
 			return
+		case <-timer.C:
 		}
 
-		time.Sleep(3 * time.Second)
+		log.Info("reconnecting", "attempt", attempt, "delay", delay)
+		m.emit(Event{Type: EventReconnectAttempt, Device: config.Name, Attempt: attempt})
+		if err := m.connectDevice(ctx, pending); err != nil {
+			lastErr = err
+			log.Warn("reconnect failed", "attempt", attempt, "error", err)
 
-		fmt.Printf("🔄 Reconnecting to %s...\n", config.Name)
-		if err := m.connectDevice(config); err != nil {
-			fmt.Printf("❌ Reconnect to %s failed: %v\n", config.Name, err)
+			if policy.exhausted(attempt) {
+				m.giveUp(pending, attempt, lastErr)
+				return
+			}
 			continue
 		}
 
-		fmt.Printf("✅ Reconnected to %s!\n", config.Name)
+		log.Info("reconnected", "attempt", attempt)
 
 		m.mu.RLock()
 		handler := m.reconnectHandler
@@ -175,81 +319,384 @@ func (m *SimpleManager) reconnectLoop(config DeviceConfig) {
 	}
 }
 
+// giveUp stops tracking a device whose reconnect policy is exhausted
+func (m *SimpleManager) giveUp(pending pendingDevice, attempts int, lastErr error) {
+	config := pending.config
+	m.untrack(pending)
+
+	m.log().Error("gave up reconnecting", "device", config.Name, "phase", "reconnect", "attempts", attempts, "error", lastErr)
+	m.emit(Event{Type: EventReconnectGaveUp, Device: config.Name, Attempt: attempts, Err: lastErr})
+
+	if config.ReconnectPolicy.OnGiveUp != nil {
+		config.ReconnectPolicy.OnGiveUp(config.Name, attempts, lastErr)
+	}
+}
+
 // ConnectToDevice connects to a single device by name and service UUID
 func (m *SimpleManager) ConnectToDevice(deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, notificationHandler func(string, []byte) error) error {
-	if err := m.enable(); err != nil {
-		return err
-	}
+	return m.ConnectToDeviceContext(context.Background(), deviceName, serviceUUID, characteristicUUID, notificationHandler)
+}
 
-	config := DeviceConfig{
+// ConnectToDeviceContext is like ConnectToDevice but honours ctx while scanning,
+// connecting and discovering services. ctx only bounds this call: once
+// connected, the device is reconnected automatically until it is
+// disconnected, its ReconnectPolicy gives up or the manager is closed.
+func (m *SimpleManager) ConnectToDeviceContext(ctx context.Context, deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, notificationHandler func(string, []byte) error) error {
+	return m.connect(ctx, DeviceConfig{
 		Name:                deviceName,
 		ServiceUUID:         serviceUUID,
 		CharacteristicUUID:  characteristicUUID,
 		NotificationHandler: notificationHandler,
+	})
+}
+
+// connect enables the adapter, registers config for reconnects and connects the device
+func (m *SimpleManager) connect(ctx context.Context, config DeviceConfig) error {
+	if err := m.enable(ctx); err != nil {
+		return err
+	}
+
+	pending := m.track(config)
+	if err := m.connectDevice(ctx, pending); err != nil {
+		m.untrack(pending)
+		return err
 	}
+	return nil
+}
 
+// track registers config for reconnects, replacing an earlier device of the
+// same name, and returns it with its lifetime
+func (m *SimpleManager) track(config DeviceConfig) pendingDevice {
+	ctx, cancel := context.WithCancel(m.ctx)
+	pending := pendingDevice{config: config, ctx: ctx, cancel: cancel}
+
+	// Cancelled under the lock, so that setupDevice sees it before registering
 	m.mu.Lock()
-	m.pendingConfigs[deviceName] = config
+	if previous, ok := m.pendingConfigs[config.Name]; ok {
+		previous.cancel()
+	}
+	m.pendingConfigs[config.Name] = pending
 	m.mu.Unlock()
+	return pending
+}
+
+// untrack ends the lifetime of pending and stops reconnecting it, unless its
+// name has been tracked again since
+func (m *SimpleManager) untrack(pending pendingDevice) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	return m.connectDevice(config)
+	if current, ok := m.pendingConfigs[pending.config.Name]; ok && current.ctx == pending.ctx {
+		delete(m.pendingConfigs, pending.config.Name)
+	}
+	pending.cancel()
+}
+
+// ConnectDevices connects all configs in one shared scanning session
+func (m *SimpleManager) ConnectDevices(configs []DeviceConfig) []ConnectResult {
+	return m.ConnectDevicesContext(context.Background(), configs)
+}
+
+// ConnectDevicesContext connects all configs in one shared scanning session.
+// Every advertisement is matched against all devices still missing, each device
+// is connected as soon as it is found, and the outcome is reported per device
+// in the order of configs. The session ends when all devices are connected,
+// DefaultScanTimeout has elapsed or ctx is cancelled. Devices bound in the
+// registry are connected directly and only scanned for if that fails. ctx
+// only bounds this call, connected devices are reconnected like with
+// ConnectToDeviceContext.
+func (m *SimpleManager) ConnectDevicesContext(ctx context.Context, configs []DeviceConfig) []ConnectResult {
+	configs = append([]DeviceConfig(nil), configs...)
+	results := make([]ConnectResult, len(configs))
+	for i, config := range configs {
+		results[i].Name = config.Name
+	}
+
+	if err := m.enable(ctx); err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+
+	// Index the devices to look for, rejecting duplicate names
+	remaining := make(map[string]int)
+	tracked := make(map[int]pendingDevice)
+	for i, config := range configs {
+		if _, exists := remaining[config.Name]; exists {
+			results[i].Err = fmt.Errorf("duplicate device name %s", config.Name)
+			continue
+		}
+		remaining[config.Name] = i
+		tracked[i] = m.track(config)
+	}
+	defer func() {
+		for i, pending := range tracked {
+			if results[i].Err != nil {
+				m.untrack(pending)
+			}
+		}
+	}()
+
+	// Connect bound devices directly, pinning the scan to their address otherwise
+	for name, i := range remaining {
+		pinned, address, ok := m.bound(configs[i])
+		if !ok {
+			continue
+		}
+		configs[i] = pinned
+		if err := m.connectDirect(ctx, tracked[i], pinned, address); err == nil {
+			delete(remaining, name)
+			results[i].Address = address.String()
+		}
+	}
+
+	scanCtx, cancel := context.WithTimeout(ctx, DefaultScanTimeout)
+	defer cancel()
+
+	for len(remaining) > 0 {
+		targets := make(map[string]Matcher, len(remaining))
+		for name, i := range remaining {
+			targets[name] = configs[i].matcher()
+		}
+
+		result, name, err := m.scanForDevices(scanCtx, targets)
+		if err != nil {
+			for name, i := range remaining {
+				results[i].Err = scanError(ctx, name, err)
+			}
+			break
+		}
+
+		i := remaining[name]
+		delete(remaining, name)
+
+		results[i].Address = result.Address.String()
+		results[i].Err = m.setupDevice(ctx, tracked[i], configs[i], result)
+	}
+
+	return results
 }
 
-// connectDevice performs the scan + connect + notification setup for one device.
-func (m *SimpleManager) connectDevice(config DeviceConfig) error {
-	result, err := m.scanForDevice(config.Name)
+// connectDevice performs the scan + connect + notification setup for a
+// tracked device. A device bound in the registry is connected directly and
+// only scanned for if that fails.
+func (m *SimpleManager) connectDevice(ctx context.Context, pending pendingDevice) error {
+	config := pending.config
+	if pinned, address, ok := m.bound(config); ok {
+		err := m.connectDirect(ctx, pending, pinned, address)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		config = pinned
+	}
+
+	result, err := m.scanForDevice(ctx, config)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("🔗 Connecting to %s [%s]...\n", config.Name, result.Address.String())
-	device, rawChannel, err := m.connectAndSetup(result, config.ServiceUUID, config.CharacteristicUUID)
+	return m.setupDevice(ctx, pending, config, result)
+}
+
+// bound returns config pinned to the address bound to it in the registry
+func (m *SimpleManager) bound(config DeviceConfig) (DeviceConfig, bluetooth.Address, bool) {
+	m.mu.RLock()
+	registry := m.registry
+	m.mu.RUnlock()
+
+	if registry == nil {
+		return config, bluetooth.Address{}, false
+	}
+	binding, ok := registry.Lookup(config.Name)
+	if !ok {
+		return config, bluetooth.Address{}, false
+	}
+
+	address, err := parseAddress(binding.Address)
+	if err != nil {
+		m.log().Warn("ignoring binding", "device", config.Name, "phase", "connect", "error", err)
+		return config, bluetooth.Address{}, false
+	}
+
+	config.Match = MatchAddress(binding.Address)
+	return config, address, true
+}
+
+// connectDirect connects to a known address without scanning first
+func (m *SimpleManager) connectDirect(ctx context.Context, pending pendingDevice, config DeviceConfig, address bluetooth.Address) error {
+	err := m.setupDevice(ctx, pending, config, bluetooth.ScanResult{Address: address})
+	if err != nil {
+		m.log().Info("direct connect failed, scanning instead", "device", config.Name, "address", address.String(),
+			"phase", "connect", "error", err)
+	}
+	return err
+}
+
+// setupDevice connects to a scanned device, sets up notifications and starts
+// handling them. config is the one of pending, possibly pinned to an address.
+func (m *SimpleManager) setupDevice(ctx context.Context, pending pendingDevice, config DeviceConfig, result bluetooth.ScanResult) error {
+	log := m.log().With("device", config.Name, "address", result.Address.String())
+	log.Info("connecting", "phase", "connect", "rssi", result.RSSI)
+	m.emit(Event{Type: EventConnecting, Device: config.Name, Address: result.Address.String()})
+	conn, subs, err := m.connectAndSetup(ctx, log, config, result)
 	if err != nil {
+		m.metrics.connectFailed(config.Name)
 		return err
 	}
 
 	simpleDevice := &SimpleDevice{
 		Name:       config.Name,
 		Address:    result.Address,
-		Device:     device,
-		rawChannel: rawChannel,
-		Channel:    rawChannel,
+		Connection: conn,
+		done:       make(chan struct{}),
 		disconnectFunc: func() {
-			device.Disconnect()
+			conn.Disconnect()
 		},
+		characteristics: make(map[characteristicKey]Characteristic),
+		responses:       make(map[characteristicKey]*responseWaiters),
+	}
+	for _, sub := range subs {
+		simpleDevice.characteristics[sub.key()] = sub.characteristic
+		if sub.channel == nil {
+			continue
+		}
+		simpleDevice.responses[sub.key()] = sub.responses
+		if simpleDevice.Channel == nil {
+			simpleDevice.Channel = sub.channel
+		}
+		simpleDevice.rawChannels = append(simpleDevice.rawChannels, sub.channel)
+	}
+	if pooled, ok := conn.(interface{ AdapterID() string }); ok {
+		simpleDevice.AdapterID = pooled.AdapterID()
+	}
+	if tc, ok := unwrapConnection(conn).(*tinyGoConnection); ok {
+		simpleDevice.Device = &tc.device
 	}
 
-	// Start a platform-specific watchdog that monitors the connection
-	// via D-Bus on Linux (where SetConnectHandler doesn't fire).
-	simpleDevice.cancelWatchdog = watchConnection(device, result.Address, func(dev bluetooth.Device) {
-		m.handleDisconnect(dev)
-	})
-
+	// The setup may have been cancelled, the device disconnected or the
+	// manager closed since the last check, and none of them would see this
+	// connection once it is registered
 	m.mu.Lock()
+	if err := m.setupAborted(ctx, pending); err != nil {
+		m.mu.Unlock()
+		conn.Disconnect()
+		m.metrics.connectFailed(config.Name)
+		log.Info("setup aborted", "phase", "ready", "error", err)
+		return err
+	}
 	m.connected[config.Name] = simpleDevice
 	m.addressToName[result.Address.String()] = config.Name
+	registry := m.registry
 	m.mu.Unlock()
 
-	go m.handleNotifications(simpleDevice, config.NotificationHandler)
+	if registry != nil {
+		localName := ""
+		if result.AdvertisementPayload != nil {
+			localName = result.LocalName()
+		}
+		if err := registry.Bind(config.Name, result.Address.String(), localName); err != nil {
+			log.Warn("failed to save binding", "phase", "ready", "error", err)
+		}
+	}
+
+	for _, sub := range subs {
+		switch sub.Mode {
+		case ModeRead:
+			go m.pollCharacteristic(simpleDevice, sub)
+		case ModePoll:
+			go m.attachReader(simpleDevice, sub)
+		default:
+			go m.handleNotifications(simpleDevice, sub)
+		}
+	}
+
+	log.Info("connected and ready", "phase", "ready")
+	m.emit(Event{Type: EventConnected, Device: config.Name, Address: result.Address.String()})
+	m.monitorHealth(simpleDevice, config.Health)
+	if config.OnConnected != nil || config.OnDisconnected != nil {
+		var primary *activeSubscription
+		if len(subs) > 0 {
+			primary = &subs[0]
+		}
+		go m.runHooks(simpleDevice, config, primary)
+	}
+	return nil
+}
 
-	fmt.Printf("🎉 %s connected and ready!\n", config.Name)
+// setupAborted returns why a device that has just been set up must not be
+// registered, if it must not. m.mu must be held.
+func (m *SimpleManager) setupAborted(ctx context.Context, pending pendingDevice) error {
+	switch {
+	case m.closing:
+		return ErrManagerClosed
+	case ctx.Err() != nil:
+		return fmt.Errorf("setup aborted: %w", ctx.Err())
+	case pending.ctx.Err() != nil:
+		return fmt.Errorf("setup aborted, device no longer wanted: %w", pending.ctx.Err())
+	}
 	return nil
 }
 
-// scanForDevice scans for a specific device by name
-func (m *SimpleManager) scanForDevice(deviceName string) (bluetooth.ScanResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// scanForDevice scans for the device described by config
+func (m *SimpleManager) scanForDevice(ctx context.Context, config DeviceConfig) (bluetooth.ScanResult, error) {
+	scanCtx, cancel := context.WithTimeout(ctx, DefaultScanTimeout)
 	defer cancel()
 
-	found := make(chan bluetooth.ScanResult, 1)
+	result, _, err := m.scanForDevices(scanCtx, map[string]Matcher{config.Name: config.matcher()})
+	if err != nil {
+		return bluetooth.ScanResult{}, scanError(ctx, config.Name, err)
+	}
+	return result, nil
+}
+
+// scanForDevices scans until an advertisement is accepted by one of the
+// targets, keyed by device name, and returns its scan result and the device
+// name. The scan is stopped before returning, so the device can be connected
+// right away (required on macOS).
+func (m *SimpleManager) scanForDevices(ctx context.Context, targets map[string]Matcher) (bluetooth.ScanResult, string, error) {
+	type match struct {
+		result bluetooth.ScanResult
+		name   string
+	}
+
+	if err := ctx.Err(); err != nil {
+		return bluetooth.ScanResult{}, "", err
+	}
+
+	// The radio runs one scan at a time. Waiting for the previous one to
+	// return also keeps its retried StopScan calls from stopping this one.
+	select {
+	case m.scanSlot <- struct{}{}:
+	case <-ctx.Done():
+		return bluetooth.ScanResult{}, "", ctx.Err()
+	}
+
+	found := make(chan match, 1)
 	scanErr := make(chan error, 1)
+	scanned := make(chan struct{})
+
+	m.emit(Event{Type: EventScanStarted})
 
 	go func() {
-		err := m.adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
-			if result.LocalName() == deviceName {
-				fmt.Printf("📱 Found %s [%s] RSSI: %d\n", deviceName, result.Address.String(), result.RSSI)
-				adapter.StopScan()
-				found <- result
+		defer func() { <-m.scanSlot }()
+		defer close(scanned)
+
+		err := m.adapter.Scan(func(result bluetooth.ScanResult) {
+			for name, accept := range targets {
+				if !accept(result) {
+					continue
+				}
+
+				m.log().Info("found device", "phase", "scan", "device", name, "address", result.Address.String(),
+					"local_name", result.LocalName(), "rssi", result.RSSI)
+				m.emit(Event{Type: EventDeviceFound, Device: name, Address: result.Address.String(), RSSI: result.RSSI})
+				m.adapter.StopScan()
+				select {
+				case found <- match{result, name}:
+				default:
+				}
+				return
 			}
 		})
 		if err != nil {
@@ -258,100 +705,234 @@ func (m *SimpleManager) scanForDevice(deviceName string) (bluetooth.ScanResult,
 	}()
 
 	select {
-	case result := <-found:
-		return result, nil
+	case hit := <-found:
+		return hit.result, hit.name, nil
 	case err := <-scanErr:
-		return bluetooth.ScanResult{}, fmt.Errorf("scan failed: %v", err)
+		return bluetooth.ScanResult{}, "", fmt.Errorf("scan failed: %v", err)
 	case <-ctx.Done():
-		m.adapter.StopScan()
-		return bluetooth.ScanResult{}, fmt.Errorf("device %s not found within 30 seconds", deviceName)
+		go retryStopScan(m.adapter, scanned)
+		return bluetooth.ScanResult{}, "", ctx.Err()
+	}
+}
+
+// scanError describes why the scan for deviceName ended without finding it,
+// distinguishing a cancelled parent context from the scan timeout
+func scanError(parent context.Context, deviceName string, err error) error {
+	if parentErr := parent.Err(); parentErr != nil {
+		return fmt.Errorf("scan for %s aborted: %w", deviceName, parentErr)
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("device %s not found within %v", deviceName, DefaultScanTimeout)
+	}
+	return err
 }
 
-// connectAndSetup establishes connection and sets up notifications
-func (m *SimpleManager) connectAndSetup(result bluetooth.ScanResult, serviceUUID, characteristicUUID bluetooth.UUID) (*bluetooth.Device, chan []byte, error) {
-	// Brief delay after stopping scan (important for macOS)
-	time.Sleep(500 * time.Millisecond)
+// connectWithContext connects to address, returning early if ctx is cancelled.
+// A connection that completes after cancellation is torn down again.
+func (m *SimpleManager) connectWithContext(ctx context.Context, adapterID string, address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	type connectResult struct {
+		conn Connection
+		err  error
+	}
+
+	connect := m.adapter.Connect
+	if adapterID != "" {
+		pool, ok := m.adapter.(*AdapterPool)
+		if !ok {
+			return nil, fmt.Errorf("device is pinned to adapter %s, but the manager has no adapter pool", adapterID)
+		}
+		connect = func(address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+			return pool.ConnectOn(adapterID, address, params)
+		}
+	}
+
+	done := make(chan connectResult, 1)
+	go func() {
+		conn, err := connect(address, params)
+		done <- connectResult{conn, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.conn, result.err
+	case <-ctx.Done():
+		go func() {
+			if result := <-done; result.err == nil {
+				result.conn.Disconnect()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
 
+// connectAndSetup establishes the connection and sets up all subscriptions of config
+func (m *SimpleManager) connectAndSetup(ctx context.Context, log *slog.Logger, config DeviceConfig, result bluetooth.ScanResult) (Connection, []activeSubscription, error) {
 	// Connect to device
-	device, err := m.adapter.Connect(result.Address, bluetooth.ConnectionParams{
+	device, err := m.connectWithContext(ctx, config.Adapter, result.Address, bluetooth.ConnectionParams{
 		ConnectionTimeout: bluetooth.NewDuration(10 * time.Second),
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("connection failed: %v", err)
+		return nil, nil, fmt.Errorf("connection failed: %w", err)
 	}
-	fmt.Println("✅ Device connected")
-
-	// Discover services
-	fmt.Println("🔍 Discovering services...")
-	services, err := device.DiscoverServices([]bluetooth.UUID{serviceUUID})
-	if err != nil {
-		device.Disconnect()
-		return nil, nil, fmt.Errorf("service discovery failed: %v", err)
+	if pooled, ok := device.(interface{ AdapterID() string }); ok {
+		log.Debug("device connected", "phase", "connect", "adapter", pooled.AdapterID())
+	} else {
+		log.Debug("device connected", "phase", "connect")
 	}
 
-	if len(services) == 0 {
-		device.Disconnect()
-		return nil, nil, fmt.Errorf("required service not found")
+	subs := config.subscriptions()
+	if len(subs) == 0 {
+		return device, nil, nil
 	}
 
-	service := services[0]
-	fmt.Printf("✅ Found service: %s\n", service.UUID().String())
-
-	// Discover characteristics
-	fmt.Println("🔍 Discovering characteristics...")
-	characteristics, err := service.DiscoverCharacteristics([]bluetooth.UUID{characteristicUUID})
+	// Discover services and characteristics
+	log.Debug("discovering services", "phase", "discover", "subscriptions", len(subs))
+	active, err := discoverSubscriptions(device, subs)
 	if err != nil {
 		device.Disconnect()
-		return nil, nil, fmt.Errorf("characteristic discovery failed: %v", err)
+		return nil, nil, err
+	}
+	for _, sub := range active {
+		log.Debug("found characteristic", "phase", "discover",
+			"service", sub.ServiceUUID.String(), "characteristic", sub.CharacteristicUUID.String())
 	}
 
-	if len(characteristics) == 0 {
+	if err := ctx.Err(); err != nil {
 		device.Disconnect()
-		return nil, nil, fmt.Errorf("required characteristic not found")
+		return nil, nil, fmt.Errorf("service discovery aborted: %w", err)
 	}
-
-	characteristic := characteristics[0]
-	fmt.Printf("✅ Found characteristic: %s\n", characteristic.UUID().String())
+	m.emit(Event{Type: EventServicesDiscovered, Device: config.Name, Address: result.Address.String()})
 
 	// Setup notifications
-	fmt.Println("🔔 Setting up notifications...")
-	rawChannel := make(chan []byte, 10)
+	for i := range active {
+		if !active[i].notifies() {
+			continue
+		}
+		if err := m.subscribe(log, config, result.Address.String(), &active[i]); err != nil {
+			device.Disconnect()
+			return nil, nil, err
+		}
+	}
+
+	return device, active, nil
+}
+
+// subscribe enables notifications of sub and creates its channel
+func (m *SimpleManager) subscribe(log *slog.Logger, config DeviceConfig, address string, sub *activeSubscription) error {
+	log = log.With("characteristic", sub.CharacteristicUUID.String())
+	log.Debug("enabling notifications", "phase", "subscribe", "mode", sub.Mode.String())
+	rawChannel := make(chan []byte, config.bufferSize())
+	responses := &responseWaiters{}
+	subscription := sub.Subscription
+
+	err := sub.characteristic.EnableNotifications(func(data []byte) {
+		m.metrics.notificationReceived(config.Name)
+		m.health.dataReceived(config.Name)
+		// Recorded on arrival, including responses and values that get dropped
+		m.record(config.Name, address, subscription, data)
+		if responses.deliver(data) {
+			return
+		}
 
-	err = characteristic.EnableNotifications(func(data []byte) {
 		defer func() {
 			if r := recover(); r != nil {
 				// Channel was closed (device disconnected) — ignore.
 			}
 		}()
-		select {
-		case rawChannel <- data:
-		default:
-			// Channel full, drop data to prevent blocking
-			fmt.Println("⚠️  Notification dropped - channel full")
+		for n := config.Overflow.enqueue(rawChannel, data); n > 0; n-- {
+			log.Warn("notification dropped, channel full", "phase", "notify", "policy", config.Overflow.String())
+			m.emit(Event{Type: EventNotificationDropped, Device: config.Name, Address: address})
 		}
 	})
 
 	if err != nil {
-		device.Disconnect()
-		return nil, nil, fmt.Errorf("failed to enable notifications: %v", err)
+		return fmt.Errorf("failed to enable notifications on %s: %v", sub.CharacteristicUUID.String(), err)
 	}
 
-	fmt.Println("✅ Notifications enabled")
-	return &device, rawChannel, nil
+	sub.channel = rawChannel
+	sub.responses = responses
+	log.Debug("notifications enabled", "phase", "subscribe")
+	return nil
 }
 
 // handleNotifications processes incoming notifications until the channel is closed.
-func (m *SimpleManager) handleNotifications(device *SimpleDevice, handler func(string, []byte) error) {
-	for data := range device.Channel {
-		if handler != nil {
-			if err := handler(device.Name, data); err != nil {
-				fmt.Printf("⚠️  Notification handler error for %s: %v\n", device.Name, err)
+func (m *SimpleManager) handleNotifications(device *SimpleDevice, sub activeSubscription) {
+	for data := range sub.channel {
+		if sub.Handler != nil {
+			if err := m.handle(device, sub.Handler, data); err != nil {
+				m.log().Warn("notification handler error", "device", device.Name, "phase", "notify", "error", err)
+			}
+		}
+	}
+}
+
+// pollCharacteristic reads a ModeRead subscription every interval until the device goes away
+func (m *SimpleManager) pollCharacteristic(device *SimpleDevice, sub activeSubscription) {
+	interval := sub.Interval
+	if interval <= 0 {
+		interval = DefaultReadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log := m.log().With("device", device.Name, "phase", "read", "characteristic", sub.CharacteristicUUID.String())
+	buf := make([]byte, maxValueSize)
+	for {
+		select {
+		case <-device.done:
+			return
+		case <-ticker.C:
+		}
+
+		n, err := sub.characteristic.Read(buf)
+		if err != nil {
+			log.Warn("read failed", "error", err)
+			continue
+		}
+		data := append([]byte(nil), buf[:min(n, len(buf))]...)
+		m.health.dataReceived(device.Name)
+		m.record(device.Name, device.Address.String(), sub.Subscription, data)
+
+		if sub.Handler != nil {
+			if err := m.handle(device, sub.Handler, data); err != nil {
+				log.Warn("read handler error", "error", err)
 			}
 		}
 	}
 }
 
+// handle calls handler with a value of device and records its latency and outcome
+func (m *SimpleManager) handle(device *SimpleDevice, handler func(deviceName string, data []byte) error, data []byte) error {
+	start := time.Now()
+	err := handler(device.Name, data)
+	m.metrics.handled(device.Name, time.Since(start), err)
+	return err
+}
+
+// attachReader hands the characteristic of a ModePoll subscription to its
+// Reader and withdraws it once the device goes away
+func (m *SimpleManager) attachReader(device *SimpleDevice, sub activeSubscription) {
+	if sub.Reader == nil {
+		return
+	}
+
+	sub.Reader(device.Name, m.readerCharacteristic(device, sub))
+	<-device.done
+	sub.Reader(device.Name, nil)
+}
+
+// readerCharacteristic wraps the characteristic of sub so values read by
+// application code count for health and are recorded
+func (m *SimpleManager) readerCharacteristic(device *SimpleDevice, sub activeSubscription) Characteristic {
+	return recordingCharacteristic{
+		Characteristic: sub.characteristic,
+		record: func(data []byte) {
+			m.health.dataReceived(device.Name)
+			m.record(device.Name, device.Address.String(), sub.Subscription, append([]byte(nil), data...))
+		},
+	}
+}
+
 // IsConnected checks if a device is connected
 func (m *SimpleManager) IsConnected(deviceName string) bool {
 	m.mu.RLock()
@@ -373,36 +954,49 @@ func (m *SimpleManager) GetConnectedDevices() map[string]*SimpleDevice {
 }
 
 // Disconnect disconnects a specific device and cancels any pending reconnect.
+// It also stops reconnecting a device whose connection was lost.
 func (m *SimpleManager) Disconnect(deviceName string) error {
 	m.mu.Lock()
 	device, exists := m.connected[deviceName]
-	if !exists {
+	pending, tracked := m.pendingConfigs[deviceName]
+	if !exists && !tracked {
 		m.mu.Unlock()
 		return fmt.Errorf("device %s not connected", deviceName)
 	}
 
-	// Remove from maps so reconnect loop won't fire
+	// Remove from maps so reconnect loop won't fire, and cancel under the
+	// lock so that a device being set up is not registered after all
 	delete(m.pendingConfigs, deviceName)
-	delete(m.connected, deviceName)
-	delete(m.addressToName, device.Address.String())
+	if tracked {
+		pending.cancel()
+	}
+	if exists {
+		delete(m.connected, deviceName)
+		delete(m.addressToName, device.Address.String())
+	}
 	m.mu.Unlock()
 
-	if device.cancelWatchdog != nil {
-		device.cancelWatchdog()
+	if !exists {
+		m.log().Info("stopped reconnecting", "device", deviceName, "phase", "disconnect")
+		return nil
 	}
+
 	if device.disconnectFunc != nil {
 		device.disconnectFunc()
 	}
-	device.closeChannel()
+	device.closeChannel(ErrDisconnectRequested)
 
-	fmt.Printf("✅ Disconnected from %s\n", deviceName)
+	m.log().Info("disconnected", "device", deviceName, "phase", "disconnect")
+	m.emit(Event{Type: EventDisconnected, Device: deviceName, Address: device.Address.String(), Err: ErrDisconnectRequested})
 	return nil
 }
 
-// Close disconnects all devices and prevents further reconnects.
+// Close disconnects all devices, prevents further reconnects and closes all
+// event subscriptions.
 func (m *SimpleManager) Close() error {
 	m.mu.Lock()
 	m.closing = true
+	m.cancel()
 
 	devices := make([]*SimpleDevice, 0, len(m.connected))
 	for _, device := range m.connected {
@@ -413,25 +1007,76 @@ func (m *SimpleManager) Close() error {
 	m.mu.Unlock()
 
 	for _, device := range devices {
-		if device.cancelWatchdog != nil {
-			device.cancelWatchdog()
-		}
 		if device.disconnectFunc != nil {
 			device.disconnectFunc()
 		}
-		device.closeChannel()
-		fmt.Printf("✅ Disconnected from %s\n", device.Name)
+		device.closeChannel(ErrManagerClosed)
+		m.log().Info("disconnected", "device", device.Name, "phase", "disconnect")
+		m.emit(Event{Type: EventDisconnected, Device: device.Name, Address: device.Address.String(), Err: ErrManagerClosed})
 	}
 
+	m.closeSubscribers()
 	return nil
 }
 
-// DeviceConfig holds configuration for a BLE device
+// DeviceConfig holds configuration for a BLE device. Name identifies the
+// device within the manager and, unless Match is set, is the advertised local
+// name to look for. ServiceUUID, CharacteristicUUID and NotificationHandler
+// declare a single notify subscription; Subscriptions adds further ones on the
+// same connection.
 type DeviceConfig struct {
 	Name                string
+	Match               Matcher // selects the device while scanning, MatchName(Name) if nil
 	ServiceUUID         bluetooth.UUID
 	CharacteristicUUID  bluetooth.UUID
 	NotificationHandler func(deviceName string, data []byte) error
+	Subscriptions       []Subscription
+	ReconnectPolicy     ReconnectPolicy // zero value retries every DefaultReconnectDelay forever
+	Adapter             string          // ID of the AdapterPool adapter to connect through, policy choice if empty
+	BufferSize          int             // notifications queued per subscription, DefaultBufferSize if zero
+	Overflow            OverflowPolicy  // what to do when a queue is full, OverflowDropNewest by default
+	Health              HealthConfig    // stale detection, RSSI sampling and liveness probe, all off by default
+
+	// OnConnected is called on every (re)connect with the characteristic of
+	// the first subscription, or nil if there is none. It must not block.
+	OnConnected func(deviceName string, characteristic Characteristic)
+	// OnDisconnected is called when a connection ends with
+	// ErrConnectionLost, ErrDisconnectRequested or ErrManagerClosed, to be
+	// compared with errors.Is
+	OnDisconnected func(deviceName string, err error)
+}
+
+// ConnectResult reports the outcome of connecting one device
+type ConnectResult struct {
+	Name    string
+	Address string // empty if the device was not found
+	Err     error
+}
+
+// ConnectError is returned when some devices of a batch could not be connected
+type ConnectError struct {
+	Results []ConnectResult
+}
+
+func (e *ConnectError) Error() string {
+	var messages []string
+	for _, result := range e.Results {
+		if result.Err != nil {
+			messages = append(messages, fmt.Sprintf("failed to connect to %s: %v", result.Name, result.Err))
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of the failed devices
+func (e *ConnectError) Unwrap() []error {
+	var errs []error
+	for _, result := range e.Results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errs
 }
 
 // Manager provides backward compatibility with the old interface
@@ -446,11 +1091,33 @@ func NewManager() *Manager {
 	}
 }
 
+// NewManagerWithAdapter creates a new BLE manager on the given adapter
+func NewManagerWithAdapter(adapter Adapter) *Manager {
+	return &Manager{
+		simpleManager: NewSimpleManagerWithAdapter(adapter),
+	}
+}
+
 // SetDisconnectHandler sets the disconnect handler (backward compatibility)
 func (m *Manager) SetDisconnectHandler(handler func(deviceName string, address string, err error)) {
 	m.simpleManager.SetDisconnectHandler(handler)
 }
 
+// SetLogger sets the logger of the manager, see SimpleManager.SetLogger
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.simpleManager.SetLogger(logger)
+}
+
+// Subscribe returns a channel of lifecycle events, see SimpleManager.Subscribe
+func (m *Manager) Subscribe(buffer int) (<-chan Event, func()) {
+	return m.simpleManager.Subscribe(buffer)
+}
+
+// SetRegistry sets the device registry, see SimpleManager.SetRegistry
+func (m *Manager) SetRegistry(registry *Registry) {
+	m.simpleManager.SetRegistry(registry)
+}
+
 // SetReconnectHandler sets the reconnect handler (backward compatibility)
 func (m *Manager) SetReconnectHandler(handler func(deviceName string, address string)) {
 	m.simpleManager.SetReconnectHandler(handler)
@@ -458,19 +1125,22 @@ func (m *Manager) SetReconnectHandler(handler func(deviceName string, address st
 
 // ConnectDevices connects to multiple devices (backward compatibility)
 func (m *Manager) ConnectDevices(configs []DeviceConfig) error {
+	return m.ConnectDevicesContext(context.Background(), configs)
+}
+
+// ConnectDevicesContext connects to multiple devices in one shared scan,
+// honouring ctx while connecting. Devices that are found are connected even
+// if others fail; the failures are reported in a *ConnectError. Connected
+// devices are reconnected until Close, independent of ctx.
+func (m *Manager) ConnectDevicesContext(ctx context.Context, configs []DeviceConfig) error {
 	if len(configs) == 0 {
 		return fmt.Errorf("no devices to connect")
 	}
 
-	for _, config := range configs {
-		err := m.simpleManager.ConnectToDevice(
-			config.Name,
-			config.ServiceUUID,
-			config.CharacteristicUUID,
-			config.NotificationHandler,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to connect to %s: %v", config.Name, err)
+	results := m.simpleManager.ConnectDevicesContext(ctx, configs)
+	for _, result := range results {
+		if result.Err != nil {
+			return &ConnectError{Results: results}
 		}
 	}
 
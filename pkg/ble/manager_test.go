@@ -0,0 +1,449 @@
+package ble_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// testTimeout bounds every wait for the manager
+const testTimeout = 2 * time.Second
+
+var (
+	testServiceUUID        = bluetooth.New16BitUUID(0x180D)
+	testCharacteristicUUID = bluetooth.New16BitUUID(0x2A37)
+)
+
+// newTestSensor adds an advertising peripheral with one notifying characteristic to adapter
+func newTestSensor(adapter *bletest.Adapter, name, address string) (*bletest.Peripheral, *bletest.Characteristic) {
+	peripheral := bletest.NewPeripheral(name, bletest.MustParseAddress(address))
+	characteristic := peripheral.AddService(testServiceUUID).AddCharacteristic(testCharacteristicUUID)
+	adapter.AddPeripheral(peripheral)
+	return peripheral, characteristic
+}
+
+// testConfig returns a config for a test sensor that forwards notifications to received
+func testConfig(name string, received chan<- []byte) ble.DeviceConfig {
+	return ble.DeviceConfig{
+		Name:               name,
+		ServiceUUID:        testServiceUUID,
+		CharacteristicUUID: testCharacteristicUUID,
+		NotificationHandler: func(deviceName string, data []byte) error {
+			received <- data
+			return nil
+		},
+		ReconnectPolicy: ble.ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}
+}
+
+// connectAll connects configs and fails the test if any of them fails
+func connectAll(t *testing.T, m *ble.SimpleManager, configs ...ble.DeviceConfig) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, result := range m.ConnectDevicesContext(ctx, configs) {
+		if result.Err != nil {
+			t.Fatalf("failed to connect %s: %v", result.Name, result.Err)
+		}
+	}
+}
+
+// nextEvent returns the next event of the given type, skipping all others
+func nextEvent(t *testing.T, events <-chan ble.Event, eventType ble.EventType) ble.Event {
+	t.Helper()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("events closed while waiting for %s", eventType)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("no %s event within %s", eventType, testTimeout)
+		}
+	}
+}
+
+// receive returns the next notification delivered to a handler
+func receive(t *testing.T, received <-chan []byte) []byte {
+	t.Helper()
+	select {
+	case data := <-received:
+		return data
+	case <-time.After(testTimeout):
+		t.Fatalf("no notification within %s", testTimeout)
+		return nil
+	}
+}
+
+// awaitClosed drains ch and fails the test unless it is closed in time
+func awaitClosed[T any](t *testing.T, ch <-chan T, what string) {
+	t.Helper()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s was not closed within %s", what, testTimeout)
+		}
+	}
+}
+
+// awaitDisconnected fails the test unless the central lets go of peripheral in time
+func awaitDisconnected(t *testing.T, peripheral *bletest.Peripheral) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for peripheral.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is still connected after %s", peripheral.Name(), testTimeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// assertStaysDisconnected fails the test if the manager connects deviceName
+// within a few advertisements of peripheral
+func assertStaysDisconnected(t *testing.T, m *ble.SimpleManager, adapter *bletest.Adapter, peripheral *bletest.Peripheral, deviceName string) {
+	t.Helper()
+	time.Sleep(10 * adapter.AdvertisementInterval)
+	if m.IsConnected(deviceName) || peripheral.IsConnected() {
+		t.Errorf("%s was connected after its setup was aborted", deviceName)
+	}
+}
+
+func TestReconnectAfterLinkLoss(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	received := make(chan []byte, 4)
+	connectAll(t, m, testConfig("Sensor", received))
+
+	if err := characteristic.Notify([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if data := receive(t, received); data[0] != 1 {
+		t.Errorf("got notification %v, want [1]", data)
+	}
+
+	peripheral.Drop()
+	event := nextEvent(t, events, ble.EventDisconnected)
+	if !errors.Is(event.Err, ble.ErrConnectionLost) {
+		t.Errorf("got disconnect reason %v, want ErrConnectionLost", event.Err)
+	}
+	if event.Device != "Sensor" || event.Address != peripheral.Address.String() {
+		t.Errorf("got disconnect of %s [%s], want Sensor [%s]", event.Device, event.Address, peripheral.Address.String())
+	}
+
+	nextEvent(t, events, ble.EventReconnectAttempt)
+	nextEvent(t, events, ble.EventConnected)
+	if !m.IsConnected("Sensor") {
+		t.Fatal("Sensor is not connected after reconnecting")
+	}
+
+	if err := characteristic.Notify([]byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if data := receive(t, received); data[0] != 2 {
+		t.Errorf("got notification %v, want [2]", data)
+	}
+}
+
+func TestReconnectOutlivesConnectContext(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := m.ConnectDevicesContext(ctx, []ble.DeviceConfig{testConfig("Sensor", make(chan []byte, 1))})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+	cancel()
+
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventDisconnected)
+	nextEvent(t, events, ble.EventConnected)
+}
+
+func TestDisconnectCancelsReconnect(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+
+	// Keep the reconnect scanning until it is cancelled
+	peripheral.SetAdvertising(false)
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventDisconnected)
+	nextEvent(t, events, ble.EventReconnectAttempt)
+
+	if err := m.Disconnect("Sensor"); err != nil {
+		t.Fatalf("Disconnect during reconnect: %v", err)
+	}
+	peripheral.SetAdvertising(true)
+
+	// A reconnect would find the peripheral within a few advertisements
+	time.Sleep(10 * adapter.AdvertisementInterval)
+	if m.IsConnected("Sensor") || peripheral.IsConnected() {
+		t.Error("Sensor was reconnected after Disconnect")
+	}
+	if err := m.Disconnect("Sensor"); err == nil {
+		t.Error("second Disconnect succeeded, want an error for an unknown device")
+	}
+}
+
+func TestDisconnectReportsRequestedDisconnect(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+	device := m.GetConnectedDevices()["Sensor"]
+
+	if err := m.Disconnect("Sensor"); err != nil {
+		t.Fatal(err)
+	}
+	event := nextEvent(t, events, ble.EventDisconnected)
+	if !errors.Is(event.Err, ble.ErrDisconnectRequested) {
+		t.Errorf("got disconnect reason %v, want ErrDisconnectRequested", event.Err)
+	}
+	if peripheral.IsConnected() {
+		t.Error("peripheral is still connected")
+	}
+	awaitClosed(t, device.Channel, "device channel")
+}
+
+func TestCloseClosesChannels(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	events, _ := m.Subscribe(0)
+
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+	device := m.GetConnectedDevices()["Sensor"]
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	awaitClosed(t, device.Channel, "device channel")
+
+	event := nextEvent(t, events, ble.EventDisconnected)
+	if !errors.Is(event.Err, ble.ErrManagerClosed) {
+		t.Errorf("got disconnect reason %v, want ErrManagerClosed", event.Err)
+	}
+	awaitClosed(t, events, "event channel")
+
+	late, _ := m.Subscribe(0)
+	awaitClosed(t, late, "subscription after Close")
+	if peripheral.IsConnected() {
+		t.Error("peripheral is still connected after Close")
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	gaveUp := make(chan int, 1)
+	config := testConfig("Sensor", make(chan []byte, 1))
+	config.ReconnectPolicy.MaxAttempts = 2
+	config.ReconnectPolicy.OnGiveUp = func(deviceName string, attempts int, lastErr error) {
+		gaveUp <- attempts
+	}
+	connectAll(t, m, config)
+
+	refused := errors.New("refused")
+	peripheral.SetConnectError(refused)
+	peripheral.Drop()
+
+	event := nextEvent(t, events, ble.EventReconnectGaveUp)
+	if event.Attempt != 2 || !errors.Is(event.Err, refused) {
+		t.Errorf("gave up after %d attempts with %v, want 2 attempts with %v", event.Attempt, event.Err, refused)
+	}
+	select {
+	case attempts := <-gaveUp:
+		if attempts != 2 {
+			t.Errorf("OnGiveUp got %d attempts, want 2", attempts)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("OnGiveUp was not called")
+	}
+	if err := m.Disconnect("Sensor"); err == nil {
+		t.Error("Disconnect succeeded for a device the manager gave up on")
+	}
+}
+
+func TestCancelDuringScan(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	// Cancelled as the advertisement comes in, so the scan still finds the device
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := testConfig("Sensor", make(chan []byte, 1))
+	config.Match = func(result bluetooth.ScanResult) bool {
+		cancel()
+		return result.LocalName() == "Sensor"
+	}
+
+	results := m.ConnectDevicesContext(ctx, []ble.DeviceConfig{config})
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", results[0].Err)
+	}
+	awaitDisconnected(t, peripheral)
+	assertStaysDisconnected(t, m, adapter, peripheral, "Sensor")
+}
+
+func TestCancelDuringSetup(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	// Cancelled after the last check of the connect path, just before the
+	// device would be registered
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	characteristic.OnSubscribe(cancel)
+
+	results := m.ConnectDevicesContext(ctx, []ble.DeviceConfig{testConfig("Sensor", make(chan []byte, 1))})
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", results[0].Err)
+	}
+	if m.IsConnected("Sensor") {
+		t.Error("Sensor is registered as connected")
+	}
+	awaitDisconnected(t, peripheral)
+	assertStaysDisconnected(t, m, adapter, peripheral, "Sensor")
+}
+
+func TestDisconnectDuringReconnectSetup(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(16)
+	defer unsubscribe()
+
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+
+	disconnected := make(chan error, 1)
+	characteristic.OnSubscribe(func() {
+		disconnected <- m.Disconnect("Sensor")
+	})
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventReconnectAttempt)
+
+	select {
+	case err := <-disconnected:
+		if err != nil {
+			t.Fatalf("Disconnect during the reconnect: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("the reconnect did not subscribe")
+	}
+	awaitDisconnected(t, peripheral)
+	assertStaysDisconnected(t, m, adapter, peripheral, "Sensor")
+
+	for len(events) > 0 {
+		if event := <-events; event.Type == ble.EventConnected {
+			t.Fatal("the aborted reconnect was reported as connected")
+		}
+	}
+}
+
+func TestCloseDuringSetup(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	characteristic.OnSubscribe(func() { m.Close() })
+
+	results := m.ConnectDevicesContext(context.Background(), []ble.DeviceConfig{testConfig("Sensor", make(chan []byte, 1))})
+	if !errors.Is(results[0].Err, ble.ErrManagerClosed) {
+		t.Errorf("got %v, want ErrManagerClosed", results[0].Err)
+	}
+	if len(m.GetConnectedDevices()) != 0 {
+		t.Error("a device was registered after Close")
+	}
+	awaitDisconnected(t, peripheral)
+}
+
+func TestConnectDevicesWithMissingDevice(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	newTestSensor(adapter, "Sensor 1", "11:22:33:44:55:01")
+	newTestSensor(adapter, "Sensor 2", "11:22:33:44:55:02")
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	// One scan looks for all three devices until the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	configs := []ble.DeviceConfig{
+		testConfig("Sensor 1", make(chan []byte, 1)),
+		testConfig("Missing", make(chan []byte, 1)),
+		testConfig("Sensor 2", make(chan []byte, 1)),
+	}
+	results := m.ConnectDevicesContext(ctx, configs)
+
+	for i, result := range results {
+		if result.Name != configs[i].Name {
+			t.Errorf("result %d is for %s, want %s", i, result.Name, configs[i].Name)
+		}
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Err != nil || !m.IsConnected(results[i].Name) {
+			t.Errorf("%s: got %v, want it connected", results[i].Name, results[i].Err)
+		}
+	}
+	if err := results[1].Err; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Missing: got %v, want context.DeadlineExceeded", err)
+	}
+
+	// The failed device is not tracked any more
+	missing, _ := newTestSensor(adapter, "Missing", "11:22:33:44:55:03")
+	assertStaysDisconnected(t, m, adapter, missing, "Missing")
+}
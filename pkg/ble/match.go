@@ -0,0 +1,94 @@
+package ble
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Matcher decides whether a scan result is the device described by a DeviceConfig.
+// Any func(bluetooth.ScanResult) bool can be used as a custom predicate.
+type Matcher func(result bluetooth.ScanResult) bool
+
+// MatchName matches devices advertising exactly the given local name
+func MatchName(name string) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		return result.LocalName() == name
+	}
+}
+
+// MatchNamePrefix matches devices whose advertised local name starts with prefix
+func MatchNamePrefix(prefix string) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		return strings.HasPrefix(result.LocalName(), prefix)
+	}
+}
+
+// MatchNameRegexp matches devices whose advertised local name matches re
+func MatchNameRegexp(re *regexp.Regexp) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		return re.MatchString(result.LocalName())
+	}
+}
+
+// MatchAddress matches the device with the given address, compared case-insensitively.
+// On macOS, addresses are the UUIDs assigned by CoreBluetooth rather than MACs.
+func MatchAddress(address string) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		return strings.EqualFold(result.Address.String(), address)
+	}
+}
+
+// MatchServiceUUID matches devices advertising the given service UUID
+func MatchServiceUUID(uuid bluetooth.UUID) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		return result.HasServiceUUID(uuid)
+	}
+}
+
+// MatchManufacturerData matches devices advertising manufacturer data of the
+// given company ID that starts with dataPrefix (nil matches any data)
+func MatchManufacturerData(companyID uint16, dataPrefix []byte) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		for _, element := range result.ManufacturerData() {
+			if element.CompanyID == companyID && bytes.HasPrefix(element.Data, dataPrefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchAll matches devices matched by every one of matchers
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		for _, match := range matchers {
+			if !match(result) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny matches devices matched by at least one of matchers
+func MatchAny(matchers ...Matcher) Matcher {
+	return func(result bluetooth.ScanResult) bool {
+		for _, match := range matchers {
+			if match(result) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matcher returns the Matcher of the config, matching on Name if none is set
+func (c DeviceConfig) matcher() Matcher {
+	if c.Match != nil {
+		return c.Match
+	}
+	return MatchName(c.Name)
+}
@@ -0,0 +1,104 @@
+package ble_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// advertisement is a bluetooth.AdvertisementPayload built from fields
+type advertisement struct {
+	localName        string
+	serviceUUIDs     []bluetooth.UUID
+	manufacturerData []bluetooth.ManufacturerDataElement
+}
+
+func (a advertisement) LocalName() string { return a.localName }
+func (a advertisement) Bytes() []byte     { return nil }
+
+func (a advertisement) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, u := range a.serviceUUIDs {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+func (a advertisement) ManufacturerData() []bluetooth.ManufacturerDataElement {
+	return a.manufacturerData
+}
+
+func (a advertisement) ServiceData() []bluetooth.ServiceDataElement { return nil }
+
+func TestMatchers(t *testing.T) {
+	tracker := bluetooth.ScanResult{
+		Address: bletest.MustParseAddress("7E:0A:12:00:00:01"),
+		AdvertisementPayload: advertisement{
+			localName:    "Timeular Tra",
+			serviceUUIDs: []bluetooth.UUID{testServiceUUID},
+			manufacturerData: []bluetooth.ManufacturerDataElement{
+				{CompanyID: 0x0059, Data: []byte{0x01, 0x02, 0x03}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		match ble.Matcher
+		want  bool
+	}{
+		{"name", ble.MatchName("Timeular Tra"), true},
+		{"other name", ble.MatchName("Timeular"), false},
+		{"name prefix", ble.MatchNamePrefix("Timeular"), true},
+		{"other name prefix", ble.MatchNamePrefix("COLUMBUS"), false},
+		{"name regexp", ble.MatchNameRegexp(regexp.MustCompile(`^Timeular\b`)), true},
+		{"other name regexp", ble.MatchNameRegexp(regexp.MustCompile(`Tracker$`)), false},
+		{"address in lower case", ble.MatchAddress("7e:0a:12:00:00:01"), true},
+		{"other address", ble.MatchAddress("7E:0A:12:00:00:02"), false},
+		{"service", ble.MatchServiceUUID(testServiceUUID), true},
+		{"other service", ble.MatchServiceUUID(bluetooth.New16BitUUID(0x180F)), false},
+		{"manufacturer", ble.MatchManufacturerData(0x0059, nil), true},
+		{"manufacturer data prefix", ble.MatchManufacturerData(0x0059, []byte{0x01, 0x02}), true},
+		{"other manufacturer data", ble.MatchManufacturerData(0x0059, []byte{0x02}), false},
+		{"other manufacturer", ble.MatchManufacturerData(0x004C, nil), false},
+		{"all", ble.MatchAll(ble.MatchNamePrefix("Timeular"), ble.MatchServiceUUID(testServiceUUID)), true},
+		{"not all", ble.MatchAll(ble.MatchNamePrefix("Timeular"), ble.MatchName("COLUMBUS Video Pen")), false},
+		{"any", ble.MatchAny(ble.MatchName("COLUMBUS Video Pen"), ble.MatchServiceUUID(testServiceUUID)), true},
+		{"none", ble.MatchAny(ble.MatchName("COLUMBUS Video Pen"), ble.MatchNamePrefix("Zei")), false},
+		{"all of nothing", ble.MatchAll(), true},
+		{"any of nothing", ble.MatchAny(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match(tracker); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectUsesMatcher(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	newTestSensor(adapter, "Sensor A1", "11:22:33:44:55:01")
+	wanted, _ := newTestSensor(adapter, "Sensor B7", "11:22:33:44:55:02")
+	wanted.AdvertiseServiceUUID(testServiceUUID)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	config := testConfig("Sensor", make(chan []byte, 1))
+	config.Match = ble.MatchAll(ble.MatchNamePrefix("Sensor"), ble.MatchServiceUUID(testServiceUUID))
+	connectAll(t, m, config)
+
+	device := m.GetConnectedDevices()["Sensor"]
+	if device == nil {
+		t.Fatal("Sensor is not connected")
+	}
+	if device.Address != wanted.Address {
+		t.Errorf("connected to %s, want %s", device.Address.String(), wanted.Address.String())
+	}
+}
@@ -0,0 +1,281 @@
+package ble
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Histogram buckets in seconds
+var (
+	connectDurationBuckets = []float64{0.5, 1, 2, 5, 10, 20, 30, 60}
+	handlerDurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+)
+
+// metrics collects the per-device counters exposed by WriteMetrics
+type metrics struct {
+	mu      sync.Mutex
+	devices map[string]*deviceMetrics
+}
+
+type deviceMetrics struct {
+	connectAttempts   uint64
+	connectFailures   uint64
+	reconnectAttempts uint64
+	connectStarted    time.Time
+	connectDuration   histogram
+	rssi              int16
+	rssiSeen          bool
+	received          uint64
+	dropped           uint64
+	handlerErrors     uint64
+	handlerDuration   histogram
+}
+
+// histogram is a cumulative Prometheus histogram
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // counts[i] observations <= buckets[i], the last entry is +Inf
+	sum     float64
+}
+
+func newHistogram(buckets []float64) histogram {
+	return histogram{buckets: buckets, counts: make([]uint64, len(buckets)+1)}
+}
+
+func (h *histogram) observe(seconds float64) {
+	for i, bound := range h.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.counts[len(h.buckets)]++
+	h.sum += seconds
+}
+
+func newMetrics() *metrics {
+	return &metrics{devices: make(map[string]*deviceMetrics)}
+}
+
+// device returns the metrics of a device, creating them on first use. m.mu must be held.
+func (m *metrics) device(name string) *deviceMetrics {
+	d, ok := m.devices[name]
+	if !ok {
+		d = &deviceMetrics{
+			connectDuration: newHistogram(connectDurationBuckets),
+			handlerDuration: newHistogram(handlerDurationBuckets),
+		}
+		m.devices[name] = d
+	}
+	return d
+}
+
+// update applies fn to the metrics of a device
+func (m *metrics) update(name string, fn func(d *deviceMetrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m.device(name))
+}
+
+// observe derives metrics from a lifecycle event
+func (m *metrics) observe(event Event) {
+	if event.Device == "" {
+		return
+	}
+
+	m.update(event.Device, func(d *deviceMetrics) {
+		switch event.Type {
+		case EventDeviceFound:
+			d.rssi = event.RSSI
+			d.rssiSeen = true
+		case EventConnecting:
+			d.connectAttempts++
+			d.connectStarted = event.Time
+		case EventConnected:
+			if !d.connectStarted.IsZero() {
+				d.connectDuration.observe(event.Time.Sub(d.connectStarted).Seconds())
+				d.connectStarted = time.Time{}
+			}
+		case EventNotificationDropped:
+			d.dropped++
+		case EventReconnectAttempt:
+			d.reconnectAttempts++
+		}
+	})
+}
+
+// setRSSI records a signal strength measured while connected
+func (m *metrics) setRSSI(name string, rssi int16) {
+	m.update(name, func(d *deviceMetrics) {
+		d.rssi = rssi
+		d.rssiSeen = true
+	})
+}
+
+// connectFailed counts a failed connection attempt
+func (m *metrics) connectFailed(name string) {
+	m.update(name, func(d *deviceMetrics) {
+		d.connectFailures++
+		d.connectStarted = time.Time{}
+	})
+}
+
+// notificationReceived counts a notification received from a device
+func (m *metrics) notificationReceived(name string) {
+	m.update(name, func(d *deviceMetrics) {
+		d.received++
+	})
+}
+
+// handled records the outcome of one handler call
+func (m *metrics) handled(name string, duration time.Duration, err error) {
+	m.update(name, func(d *deviceMetrics) {
+		d.handlerDuration.observe(duration.Seconds())
+		if err != nil {
+			d.handlerErrors++
+		}
+	})
+}
+
+// dropped returns the dropped-notification count of a device
+func (m *metrics) dropped(name string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.devices[name]; ok {
+		return d.dropped
+	}
+	return 0
+}
+
+// WriteMetrics writes the manager metrics in the Prometheus text exposition
+// format. Counters are kept per logical device name and survive reconnects.
+func (m *SimpleManager) WriteMetrics(w io.Writer) error {
+	m.mu.RLock()
+	connected := len(m.connected)
+	m.mu.RUnlock()
+
+	m.metrics.mu.Lock()
+	names := make([]string, 0, len(m.metrics.devices))
+	for name := range m.metrics.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	devices := make([]deviceMetrics, len(names))
+	for i, name := range names {
+		devices[i] = *m.metrics.devices[name]
+		devices[i].connectDuration.counts = append([]uint64(nil), devices[i].connectDuration.counts...)
+		devices[i].handlerDuration.counts = append([]uint64(nil), devices[i].handlerDuration.counts...)
+	}
+	m.metrics.mu.Unlock()
+
+	e := &exposition{w: bufio.NewWriter(w)}
+
+	e.header("ble_connected_devices", "gauge", "Number of currently connected devices.")
+	e.sample("ble_connected_devices", "", float64(connected))
+
+	counters := []struct {
+		name, help string
+		value      func(d *deviceMetrics) uint64
+	}{
+		{"ble_connect_attempts_total", "Connection attempts per device.", func(d *deviceMetrics) uint64 { return d.connectAttempts }},
+		{"ble_connect_failures_total", "Failed connection attempts per device.", func(d *deviceMetrics) uint64 { return d.connectFailures }},
+		{"ble_reconnect_attempts_total", "Reconnect attempts after a lost connection per device.", func(d *deviceMetrics) uint64 { return d.reconnectAttempts }},
+		{"ble_notifications_received_total", "Notifications received per device.", func(d *deviceMetrics) uint64 { return d.received }},
+		{"ble_notifications_dropped_total", "Notifications lost to the overflow policy per device.", func(d *deviceMetrics) uint64 { return d.dropped }},
+		{"ble_handler_errors_total", "Handler calls that returned an error per device.", func(d *deviceMetrics) uint64 { return d.handlerErrors }},
+	}
+	for _, counter := range counters {
+		e.header(counter.name, "counter", counter.help)
+		for i := range devices {
+			e.sample(counter.name, deviceLabel(names[i]), float64(counter.value(&devices[i])))
+		}
+	}
+
+	e.header("ble_rssi_dbm", "gauge", "Last signal strength measured per device.")
+	for i := range devices {
+		if devices[i].rssiSeen {
+			e.sample("ble_rssi_dbm", deviceLabel(names[i]), float64(devices[i].rssi))
+		}
+	}
+
+	e.header("ble_connect_duration_seconds", "histogram", "Time from connecting to a found device until it is ready.")
+	for i := range devices {
+		e.histogram("ble_connect_duration_seconds", deviceLabel(names[i]), &devices[i].connectDuration)
+	}
+
+	e.header("ble_handler_duration_seconds", "histogram", "Time spent in notification and read handlers.")
+	for i := range devices {
+		e.histogram("ble_handler_duration_seconds", deviceLabel(names[i]), &devices[i].handlerDuration)
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// MetricsHandler returns an http.Handler serving WriteMetrics, e.g. on /metrics
+func (m *SimpleManager) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.WriteMetrics(w); err != nil {
+			m.log().Warn("failed to write metrics", "phase", "metrics", "error", err)
+		}
+	})
+}
+
+// WriteMetrics writes the manager metrics in the Prometheus text exposition format
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	return m.simpleManager.WriteMetrics(w)
+}
+
+// MetricsHandler returns an http.Handler serving the manager metrics
+func (m *Manager) MetricsHandler() http.Handler {
+	return m.simpleManager.MetricsHandler()
+}
+
+// exposition writes the Prometheus text format, remembering the first error
+type exposition struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *exposition) printf(format string, args ...any) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+func (e *exposition) header(name, kind, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (e *exposition) sample(name, labels string, value float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	e.printf("%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (e *exposition) histogram(name, labels string, h *histogram) {
+	for i, bound := range h.buckets {
+		e.sample(name+"_bucket", labels+`,le="`+strconv.FormatFloat(bound, 'g', -1, 64)+`"`, float64(h.counts[i]))
+	}
+	count := h.counts[len(h.buckets)]
+	e.sample(name+"_bucket", labels+`,le="+Inf"`, float64(count))
+	e.sample(name+"_sum", labels, h.sum)
+	e.sample(name+"_count", labels, float64(count))
+}
+
+// labelEscaper escapes label values as required by the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func deviceLabel(name string) string {
+	return `device="` + labelEscaper.Replace(name) + `"`
+}
@@ -0,0 +1,105 @@
+package ble_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+)
+
+// awaitMetrics polls WriteMetrics until it contains every line of want, as
+// handlers are timed after the values they handle have been counted
+func awaitMetrics(t *testing.T, m *ble.SimpleManager, want ...string) string {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for {
+		var buf bytes.Buffer
+		if err := m.WriteMetrics(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := make(map[string]bool)
+		for _, line := range strings.Split(buf.String(), "\n") {
+			lines[line] = true
+		}
+		var missing []string
+		for _, line := range want {
+			if !lines[line] {
+				missing = append(missing, line)
+			}
+		}
+		if len(missing) == 0 {
+			return buf.String()
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics lack\n%s\ngot\n%s", strings.Join(missing, "\n"), buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, `Sensor "A"`, "11:22:33:44:55:66")
+	peripheral.SetRSSI(-60)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	// The first notification holds the handler, so the third overflows the queue of one
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	config := testConfig(`Sensor "A"`, nil)
+	config.BufferSize = 1
+	config.NotificationHandler = func(deviceName string, data []byte) error {
+		switch data[0] {
+		case 1:
+			close(entered)
+			<-release
+		case 2:
+			return errors.New("bad value")
+		}
+		return nil
+	}
+	connectAll(t, m, config)
+
+	for i := byte(1); i <= 3; i++ {
+		if err := characteristic.Notify([]byte{i}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			<-entered
+		}
+	}
+	close(release)
+
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventConnected)
+
+	const device = `{device="Sensor \"A\""}`
+	awaitMetrics(t, m,
+		"# TYPE ble_connected_devices gauge",
+		"ble_connected_devices 1",
+		"# TYPE ble_connect_attempts_total counter",
+		"ble_connect_attempts_total"+device+" 2",
+		"ble_connect_failures_total"+device+" 0",
+		"ble_reconnect_attempts_total"+device+" 1",
+		"ble_notifications_received_total"+device+" 3",
+		"ble_notifications_dropped_total"+device+" 1",
+		"ble_handler_errors_total"+device+" 1",
+		"ble_rssi_dbm"+device+" -60",
+		"# TYPE ble_connect_duration_seconds histogram",
+		`ble_connect_duration_seconds_bucket{device="Sensor \"A\"",le="+Inf"} 2`,
+		"ble_connect_duration_seconds_count"+device+" 2",
+		"ble_handler_duration_seconds_count"+device+" 2",
+	)
+	if dropped := m.DroppedNotifications(`Sensor "A"`); dropped != 1 {
+		t.Errorf("DroppedNotifications = %d, want 1", dropped)
+	}
+}
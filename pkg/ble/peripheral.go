@@ -0,0 +1,103 @@
+package ble
+
+import (
+	"context"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Peripheral is implemented by the device types of the device packages, e.g.
+// columbus.Device and timeular.Device, so the manager can connect them with
+// Register instead of a hand-built DeviceConfig
+type Peripheral interface {
+	GetName() string
+	GetServiceUUID() bluetooth.UUID
+	GetCharacteristicUUID() bluetooth.UUID
+	ProcessNotification(deviceName string, data []byte) error
+}
+
+// ConnectedHook is implemented by peripherals that act on every (re)connect.
+// characteristic is the one of GetCharacteristicUUID; it must not block.
+type ConnectedHook interface {
+	OnConnected(deviceName string, characteristic Characteristic)
+}
+
+// DisconnectedHook is implemented by peripherals that reset state when the
+// connection ends, whether lost, requested or closed with the manager
+type DisconnectedHook interface {
+	OnDisconnected(deviceName string, err error)
+}
+
+// PeripheralConfigurer is implemented by peripherals that adjust the config
+// derived by PeripheralConfig, e.g. to poll instead of subscribing
+type PeripheralConfigurer interface {
+	ConfigureDevice(config *DeviceConfig)
+}
+
+// PeripheralConfig derives the DeviceConfig of a peripheral: a notify
+// subscription to its characteristic handled by ProcessNotification, and its
+// lifecycle hooks. The result can be adjusted further before connecting it.
+func PeripheralConfig(p Peripheral) DeviceConfig {
+	config := DeviceConfig{
+		Name: p.GetName(),
+		Subscriptions: []Subscription{{
+			ServiceUUID:        p.GetServiceUUID(),
+			CharacteristicUUID: p.GetCharacteristicUUID(),
+			Mode:               ModeNotify,
+			Handler:            p.ProcessNotification,
+		}},
+	}
+	if hook, ok := p.(ConnectedHook); ok {
+		config.OnConnected = hook.OnConnected
+	}
+	if hook, ok := p.(DisconnectedHook); ok {
+		config.OnDisconnected = hook.OnDisconnected
+	}
+	if configurer, ok := p.(PeripheralConfigurer); ok {
+		configurer.ConfigureDevice(&config)
+	}
+	return config
+}
+
+// Register connects peripherals with the configs derived by PeripheralConfig
+func (m *SimpleManager) Register(peripherals ...Peripheral) []ConnectResult {
+	return m.RegisterContext(context.Background(), peripherals...)
+}
+
+// RegisterContext connects peripherals like ConnectDevicesContext
+func (m *SimpleManager) RegisterContext(ctx context.Context, peripherals ...Peripheral) []ConnectResult {
+	return m.ConnectDevicesContext(ctx, peripheralConfigs(peripherals))
+}
+
+func peripheralConfigs(peripherals []Peripheral) []DeviceConfig {
+	configs := make([]DeviceConfig, len(peripherals))
+	for i, p := range peripherals {
+		configs[i] = PeripheralConfig(p)
+	}
+	return configs
+}
+
+// Register connects peripherals with the configs derived by PeripheralConfig
+func (m *Manager) Register(peripherals ...Peripheral) error {
+	return m.RegisterContext(context.Background(), peripherals...)
+}
+
+// RegisterContext connects peripherals, see Manager.ConnectDevicesContext
+func (m *Manager) RegisterContext(ctx context.Context, peripherals ...Peripheral) error {
+	return m.ConnectDevicesContext(ctx, peripheralConfigs(peripherals))
+}
+
+// runHooks calls the lifecycle hooks of config for one connection of device
+func (m *SimpleManager) runHooks(device *SimpleDevice, config DeviceConfig, primary *activeSubscription) {
+	if config.OnConnected != nil {
+		var characteristic Characteristic
+		if primary != nil {
+			characteristic = m.readerCharacteristic(device, *primary)
+		}
+		config.OnConnected(device.Name, characteristic)
+	}
+	<-device.done
+	if config.OnDisconnected != nil {
+		config.OnDisconnected(device.Name, device.reason)
+	}
+}
@@ -0,0 +1,119 @@
+package ble_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// hookedSensor is a test sensor connected with Register that reports its
+// notifications and lifecycle hooks on channels
+type hookedSensor struct {
+	name         string
+	received     chan []byte
+	connected    chan ble.Characteristic
+	disconnected chan error
+}
+
+func newHookedSensor(name string) *hookedSensor {
+	return &hookedSensor{
+		name:         name,
+		received:     make(chan []byte, 4),
+		connected:    make(chan ble.Characteristic, 2),
+		disconnected: make(chan error, 2),
+	}
+}
+
+func (s *hookedSensor) GetName() string                       { return s.name }
+func (s *hookedSensor) GetServiceUUID() bluetooth.UUID        { return testServiceUUID }
+func (s *hookedSensor) GetCharacteristicUUID() bluetooth.UUID { return testCharacteristicUUID }
+
+func (s *hookedSensor) ProcessNotification(deviceName string, data []byte) error {
+	s.received <- data
+	return nil
+}
+
+func (s *hookedSensor) OnConnected(deviceName string, characteristic ble.Characteristic) {
+	s.connected <- characteristic
+}
+
+func (s *hookedSensor) OnDisconnected(deviceName string, err error) {
+	s.disconnected <- err
+}
+
+// ConfigureDevice reconnects quickly
+func (s *hookedSensor) ConfigureDevice(config *ble.DeviceConfig) {
+	config.ReconnectPolicy = testConfig(s.name, nil).ReconnectPolicy
+}
+
+// awaitHook returns the next value a hook sent on ch
+func awaitHook[T any](t *testing.T, ch <-chan T, hook string) T {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(testTimeout):
+		t.Fatalf("%s was not called within %s", hook, testTimeout)
+		var zero T
+		return zero
+	}
+}
+
+func TestRegister(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+	characteristic.SetValue([]byte{42})
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	sensor := newHookedSensor("Sensor")
+	for _, result := range m.Register(sensor) {
+		if result.Err != nil {
+			t.Fatalf("failed to register %s: %v", result.Name, result.Err)
+		}
+	}
+
+	// OnConnected receives the characteristic of the peripheral
+	char := awaitHook(t, sensor.connected, "OnConnected")
+	if char == nil {
+		t.Fatal("OnConnected got no characteristic")
+	}
+	buf := make([]byte, 4)
+	n, err := char.Read(buf)
+	if err != nil || !bytes.Equal(buf[:n], []byte{42}) {
+		t.Errorf("characteristic read %v, %v, want [42]", buf[:n], err)
+	}
+
+	// Notifications go to ProcessNotification
+	if err := characteristic.Notify([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if data := receive(t, sensor.received); !bytes.Equal(data, []byte{1}) {
+		t.Errorf("ProcessNotification got %v, want [1]", data)
+	}
+
+	// Both hooks run again for every reconnect
+	peripheral.Drop()
+	if err := awaitHook(t, sensor.disconnected, "OnDisconnected"); !errors.Is(err, ble.ErrConnectionLost) {
+		t.Errorf("OnDisconnected got %v, want ErrConnectionLost", err)
+	}
+	nextEvent(t, events, ble.EventConnected)
+	if char := awaitHook(t, sensor.connected, "OnConnected after the reconnect"); char == nil {
+		t.Error("OnConnected got no characteristic after the reconnect")
+	}
+
+	if err := m.Disconnect("Sensor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := awaitHook(t, sensor.disconnected, "OnDisconnected"); !errors.Is(err, ble.ErrDisconnectRequested) {
+		t.Errorf("OnDisconnected got %v, want ErrDisconnectRequested", err)
+	}
+}
@@ -0,0 +1,468 @@
+package ble
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/internal/logging"
+	"tinygo.org/x/bluetooth"
+)
+
+// PoolPolicy decides which adapter of an AdapterPool connects a device that
+// is not pinned with DeviceConfig.Adapter
+type PoolPolicy int
+
+const (
+	// PoolRoundRobin takes the adapters in turn
+	PoolRoundRobin PoolPolicy = iota
+	// PoolLeastLoaded takes the adapter with the fewest connections
+	PoolLeastLoaded
+	// PoolPinned refuses devices that are not pinned to an adapter
+	PoolPinned
+)
+
+func (p PoolPolicy) String() string {
+	switch p {
+	case PoolRoundRobin:
+		return "round-robin"
+	case PoolLeastLoaded:
+		return "least-loaded"
+	case PoolPinned:
+		return "pinned"
+	default:
+		return "unknown"
+	}
+}
+
+// PoolAdapter is one adapter of an AdapterPool
+type PoolAdapter struct {
+	ID      string // e.g. "hci1", referenced by DeviceConfig.Adapter
+	Adapter Adapter
+}
+
+// AdapterPool spreads devices across several radios. It scans on all of them
+// and connects every device through one adapter: the pinned one, the one it
+// was connected through before, or else one picked by the policy. The pool
+// implements Adapter, so it is passed to NewSimpleManagerWithAdapter like a
+// single adapter.
+//
+// Which adapters received an advertisement is not taken into account, since
+// not every Adapter can tell: the tinygo stack on Linux reports devices found
+// by any adapter to all of them.
+type AdapterPool struct {
+	members           []*poolMember
+	policy            PoolPolicy
+	next              int                    // round-robin position
+	placement         map[string]*poolMember // address -> adapter of the last connection
+	connectHandler    func(address bluetooth.Address, connected bool)
+	disconnectHandler func(address bluetooth.Address, err error)
+	scanStop          chan struct{} // closed by StopScan, nil while not scanning
+	scanDone          chan struct{} // closed once the last scan has returned on all adapters
+	logger            atomic.Pointer[slog.Logger]
+	scanMu            sync.Mutex // serializes scan callbacks of all adapters
+	mu                sync.Mutex
+}
+
+type poolMember struct {
+	PoolAdapter
+	enabled     bool
+	connections map[string]*poolConnection // by address
+}
+
+// NewAdapterPool creates a pool of the given adapters
+func NewAdapterPool(policy PoolPolicy, adapters ...PoolAdapter) *AdapterPool {
+	p := &AdapterPool{
+		policy:    policy,
+		placement: make(map[string]*poolMember),
+	}
+	for _, adapter := range adapters {
+		p.members = append(p.members, &poolMember{
+			PoolAdapter: adapter,
+			connections: make(map[string]*poolConnection),
+		})
+	}
+	p.logger.Store(logging.Discard())
+	return p
+}
+
+// NewAdapterPoolByID creates a pool of the BlueZ adapters with the given IDs (Linux only)
+func NewAdapterPoolByID(policy PoolPolicy, ids ...string) (*AdapterPool, error) {
+	adapters := make([]PoolAdapter, len(ids))
+	for i, id := range ids {
+		adapter, err := NewAdapterByID(id)
+		if err != nil {
+			return nil, err
+		}
+		adapters[i] = PoolAdapter{ID: id, Adapter: adapter}
+	}
+	return NewAdapterPool(policy, adapters...), nil
+}
+
+// SetLogger sets the logger of the pool and of every adapter that has one
+func (p *AdapterPool) SetLogger(logger *slog.Logger) {
+	logger = logging.OrDiscard(logger)
+	p.logger.Store(logger)
+
+	for _, member := range p.members {
+		if adapter, ok := member.Adapter.(interface{ SetLogger(*slog.Logger) }); ok {
+			adapter.SetLogger(logger)
+		}
+	}
+}
+
+// Enable enables every adapter. Adapters that fail are left out of the pool;
+// Enable only fails if none could be enabled.
+func (p *AdapterPool) Enable() error {
+	var errs []error
+	for _, member := range p.members {
+		if err := member.Adapter.Enable(); err != nil {
+			p.logger.Load().Warn("adapter unavailable", "adapter", member.ID, "phase", "enable", "error", err)
+			errs = append(errs, fmt.Errorf("adapter %s: %w", member.ID, err))
+			continue
+		}
+
+		member := member
+		member.Adapter.SetConnectHandler(func(address bluetooth.Address, connected bool) {
+			p.connectionChanged(member, address, connected)
+		})
+		if reporter, ok := member.Adapter.(DisconnectReporter); ok {
+			reporter.SetDisconnectHandler(func(address bluetooth.Address, err error) {
+				p.disconnected(member, address, err)
+			})
+		}
+
+		p.mu.Lock()
+		member.enabled = true
+		p.mu.Unlock()
+	}
+
+	if len(errs) == len(p.members) {
+		return errors.Join(append([]error{errors.New("no adapter could be enabled")}, errs...)...)
+	}
+	return nil
+}
+
+// Scan scans on all enabled adapters until StopScan is called. callback is
+// never called concurrently.
+func (p *AdapterPool) Scan(callback func(result bluetooth.ScanResult)) error {
+	members := p.enabled()
+	if len(members) == 0 {
+		return errors.New("no adapter enabled")
+	}
+
+	p.mu.Lock()
+	if p.scanStop != nil {
+		p.mu.Unlock()
+		return errors.New("already scanning")
+	}
+	previous := p.scanDone
+	stop, done := make(chan struct{}), make(chan struct{})
+	p.scanStop, p.scanDone = stop, done
+	p.mu.Unlock()
+	defer close(done)
+
+	// A stopped scan may still be winding down, and its retried StopScan
+	// calls would stop this one
+	if previous != nil {
+		<-previous
+	}
+
+	defer func() {
+		p.mu.Lock()
+		if p.scanStop == stop {
+			p.scanStop = nil
+		}
+		p.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(members))
+	for i, member := range members {
+		wg.Add(2)
+		scanned := make(chan struct{})
+		go func(member *poolMember) {
+			defer wg.Done()
+			stopMember(member, stop, scanned)
+		}(member)
+		go func(i int, member *poolMember) {
+			defer wg.Done()
+			defer close(scanned)
+			errs[i] = member.Adapter.Scan(func(result bluetooth.ScanResult) {
+				p.scanMu.Lock()
+				defer p.scanMu.Unlock()
+				callback(result)
+			})
+			if errs[i] != nil {
+				p.logger.Load().Warn("scan failed", "adapter", member.ID, "phase", "scan", "error", errs[i])
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopMember stops the scan of member once stop is closed
+func stopMember(member *poolMember, stop, done <-chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-stop:
+	}
+	retryStopScan(member.Adapter, done)
+}
+
+// StopScan stops the scan on all adapters. Like with a single adapter, the
+// next scan can be started right away.
+func (p *AdapterPool) StopScan() error {
+	p.mu.Lock()
+	stop := p.scanStop
+	p.scanStop = nil
+	p.mu.Unlock()
+
+	if stop == nil {
+		return errors.New("not scanning")
+	}
+	close(stop)
+	for _, member := range p.enabled() {
+		member.Adapter.StopScan()
+	}
+	return nil
+}
+
+// Connect connects to a device through the adapter chosen by the policy
+func (p *AdapterPool) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	member, err := p.choose(address)
+	if err != nil {
+		return nil, err
+	}
+	return p.connect(member, address, params)
+}
+
+// ConnectOn connects to a device through the adapter with the given ID
+func (p *AdapterPool) ConnectOn(adapterID string, address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	p.mu.Lock()
+	var member *poolMember
+	for _, candidate := range p.members {
+		if candidate.ID == adapterID {
+			member = candidate
+		}
+	}
+	enabled := member != nil && member.enabled
+	p.mu.Unlock()
+
+	if member == nil {
+		return nil, fmt.Errorf("no adapter %s in pool", adapterID)
+	}
+	if !enabled {
+		return nil, fmt.Errorf("adapter %s is not enabled", adapterID)
+	}
+	return p.connect(member, address, params)
+}
+
+func (p *AdapterPool) SetConnectHandler(handler func(address bluetooth.Address, connected bool)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connectHandler = handler
+}
+
+// SetDisconnectHandler implements DisconnectReporter for the adapters of the
+// pool that implement it
+func (p *AdapterPool) SetDisconnectHandler(handler func(address bluetooth.Address, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disconnectHandler = handler
+}
+
+// AdapterOf returns the ID of the adapter a device is connected through
+func (p *AdapterPool) AdapterOf(address bluetooth.Address) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, member := range p.members {
+		if _, ok := member.connections[address.String()]; ok {
+			return member.ID, true
+		}
+	}
+	return "", false
+}
+
+// Connections returns the number of connected devices per adapter ID
+func (p *AdapterPool) Connections() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := make(map[string]int, len(p.members))
+	for _, member := range p.members {
+		result[member.ID] = len(member.connections)
+	}
+	return result
+}
+
+// enabled returns the adapters that could be enabled
+func (p *AdapterPool) enabled() []*poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var members []*poolMember
+	for _, member := range p.members {
+		if member.enabled {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// choose picks the adapter for an unpinned device
+func (p *AdapterPool) choose(address bluetooth.Address) (*poolMember, error) {
+	if p.policy == PoolPinned {
+		return nil, fmt.Errorf("device %s is not pinned to an adapter", address.String())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*poolMember
+	for _, member := range p.members {
+		if member.enabled {
+			candidates = append(candidates, member)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no adapter enabled")
+	}
+
+	// A reconnecting device stays on its adapter
+	if previous, ok := p.placement[address.String()]; ok {
+		for _, candidate := range candidates {
+			if candidate == previous {
+				return previous, nil
+			}
+		}
+	}
+
+	switch p.policy {
+	case PoolLeastLoaded:
+		best := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if len(candidate.connections) < len(best.connections) ||
+				len(candidate.connections) == len(best.connections) && p.index(candidate) < p.index(best) {
+				best = candidate
+			}
+		}
+		return best, nil
+	default:
+		// Next adapter in pool order that is a candidate
+		for i := 0; i < len(p.members); i++ {
+			member := p.members[(p.next+i)%len(p.members)]
+			for _, candidate := range candidates {
+				if candidate == member {
+					p.next = (p.index(member) + 1) % len(p.members)
+					return member, nil
+				}
+			}
+		}
+		return candidates[0], nil
+	}
+}
+
+// index returns the position of member in the pool. p.mu must be held.
+func (p *AdapterPool) index(member *poolMember) int {
+	for i, candidate := range p.members {
+		if candidate == member {
+			return i
+		}
+	}
+	return -1
+}
+
+// connect connects through member and tracks the connection until it ends
+func (p *AdapterPool) connect(member *poolMember, address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	p.logger.Load().Debug("connecting through adapter", "adapter", member.ID, "address", address.String(), "phase", "connect")
+	conn, err := member.Adapter.Connect(address, params)
+	if err != nil {
+		return nil, fmt.Errorf("adapter %s: %w", member.ID, err)
+	}
+
+	pc := &poolConnection{Connection: conn, pool: p, member: member}
+	p.mu.Lock()
+	member.connections[address.String()] = pc
+	p.placement[address.String()] = member
+	p.mu.Unlock()
+	return pc, nil
+}
+
+// connectionChanged forwards a connection state change of member
+func (p *AdapterPool) connectionChanged(member *poolMember, address bluetooth.Address, connected bool) {
+	if !connected {
+		p.disconnected(member, address, nil)
+		return
+	}
+
+	p.mu.Lock()
+	handler := p.connectHandler
+	p.mu.Unlock()
+
+	if handler != nil {
+		handler(address, true)
+	}
+}
+
+// disconnected releases a connection of member that ended and forwards the
+// disconnect like the adapter reported it
+func (p *AdapterPool) disconnected(member *poolMember, address bluetooth.Address, err error) {
+	p.mu.Lock()
+	delete(member.connections, address.String())
+	connectHandler, disconnectHandler := p.connectHandler, p.disconnectHandler
+	p.mu.Unlock()
+
+	switch {
+	case disconnectHandler != nil:
+		disconnectHandler(address, err)
+	case connectHandler != nil:
+		connectHandler(address, false)
+	}
+}
+
+// poolConnection is a Connection made through one adapter of a pool
+type poolConnection struct {
+	Connection
+	pool   *AdapterPool
+	member *poolMember
+}
+
+// AdapterID returns the ID of the adapter the connection was made through
+func (c *poolConnection) AdapterID() string {
+	return c.member.ID
+}
+
+// Unwrap returns the connection of the underlying adapter
+func (c *poolConnection) Unwrap() Connection {
+	return c.Connection
+}
+
+func (c *poolConnection) Disconnect() error {
+	c.pool.mu.Lock()
+	if c.member.connections[c.Address().String()] == c {
+		delete(c.member.connections, c.Address().String())
+	}
+	c.pool.mu.Unlock()
+
+	return c.Connection.Disconnect()
+}
+
+// RSSI implements RSSIReader if the underlying connection does
+func (c *poolConnection) RSSI() (int16, error) {
+	if reader, ok := c.Connection.(RSSIReader); ok {
+		return reader.RSSI()
+	}
+	return 0, errors.ErrUnsupported
+}
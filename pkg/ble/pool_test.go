@@ -0,0 +1,199 @@
+package ble_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// newTestPool returns a pool of n in-memory adapters with the IDs hci0, hci1, ...
+func newTestPool(policy ble.PoolPolicy, n int) (*ble.AdapterPool, []*bletest.Adapter) {
+	adapters := make([]*bletest.Adapter, n)
+	members := make([]ble.PoolAdapter, n)
+	for i := range adapters {
+		adapters[i] = bletest.NewAdapter()
+		members[i] = ble.PoolAdapter{ID: fmt.Sprintf("hci%d", i), Adapter: adapters[i]}
+	}
+	return ble.NewAdapterPool(policy, members...), adapters
+}
+
+// newSensorInRange adds a test sensor that all adapters can reach
+func newSensorInRange(adapters []*bletest.Adapter, name, address string) *bletest.Peripheral {
+	peripheral, _ := newTestSensor(adapters[0], name, address)
+	for _, adapter := range adapters[1:] {
+		adapter.AddPeripheral(peripheral)
+	}
+	return peripheral
+}
+
+// connectedThrough connects config and returns the adapter it was placed on
+func connectedThrough(t *testing.T, m *ble.SimpleManager, config ble.DeviceConfig) string {
+	t.Helper()
+	connectAll(t, m, config)
+	device, ok := m.GetConnectedDevices()[config.Name]
+	if !ok {
+		t.Fatalf("%s is not connected", config.Name)
+	}
+	return device.AdapterID
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	pool, adapters := newTestPool(ble.PoolRoundRobin, 2)
+	m := ble.NewSimpleManagerWithAdapter(pool)
+	defer m.Close()
+
+	want := []string{"hci0", "hci1", "hci0"}
+	for i, adapterID := range want {
+		name := fmt.Sprintf("Sensor %d", i)
+		newSensorInRange(adapters, name, fmt.Sprintf("11:22:33:44:55:%02X", i))
+		if got := connectedThrough(t, m, testConfig(name, make(chan []byte, 1))); got != adapterID {
+			t.Errorf("%s connected through %s, want %s", name, got, adapterID)
+		}
+	}
+
+	connections := pool.Connections()
+	if connections["hci0"] != 2 || connections["hci1"] != 1 {
+		t.Errorf("got connections %v, want 2 on hci0 and 1 on hci1", connections)
+	}
+}
+
+func TestPoolLeastLoaded(t *testing.T) {
+	pool, adapters := newTestPool(ble.PoolLeastLoaded, 2)
+	m := ble.NewSimpleManagerWithAdapter(pool)
+	defer m.Close()
+
+	for i, name := range []string{"Pinned 1", "Pinned 2"} {
+		newSensorInRange(adapters, name, fmt.Sprintf("11:22:33:44:55:%02X", i))
+		config := testConfig(name, make(chan []byte, 1))
+		config.Adapter = "hci0"
+		if got := connectedThrough(t, m, config); got != "hci0" {
+			t.Fatalf("pinned %s connected through %s, want hci0", name, got)
+		}
+	}
+
+	// hci1 has fewer connections until both carry two
+	for i, name := range []string{"Free 1", "Free 2"} {
+		newSensorInRange(adapters, name, fmt.Sprintf("11:22:33:44:66:%02X", i))
+		if got := connectedThrough(t, m, testConfig(name, make(chan []byte, 1))); got != "hci1" {
+			t.Errorf("%s connected through %s, want the least loaded hci1", name, got)
+		}
+	}
+
+	// On a tie the first adapter of the pool wins
+	newSensorInRange(adapters, "Free 3", "11:22:33:44:66:02")
+	if got := connectedThrough(t, m, testConfig("Free 3", make(chan []byte, 1))); got != "hci0" {
+		t.Errorf("Free 3 connected through %s, want hci0 on a tie", got)
+	}
+}
+
+func TestPoolPinned(t *testing.T) {
+	pool, adapters := newTestPool(ble.PoolPinned, 2)
+	m := ble.NewSimpleManagerWithAdapter(pool)
+	defer m.Close()
+
+	newSensorInRange(adapters, "Pinned", "11:22:33:44:55:01")
+	newSensorInRange(adapters, "Unpinned", "11:22:33:44:55:02")
+
+	pinned := testConfig("Pinned", make(chan []byte, 1))
+	pinned.Adapter = "hci1"
+	unpinned := testConfig("Unpinned", make(chan []byte, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	results := m.ConnectDevicesContext(ctx, []ble.DeviceConfig{pinned, unpinned})
+
+	if results[0].Err != nil {
+		t.Fatalf("pinned device failed to connect: %v", results[0].Err)
+	}
+	if device := m.GetConnectedDevices()["Pinned"]; device == nil || device.AdapterID != "hci1" {
+		t.Errorf("pinned device is not connected through hci1")
+	}
+	if results[1].Err == nil {
+		t.Error("unpinned device was connected under PoolPinned")
+	}
+	if connections := pool.Connections(); connections["hci0"] != 0 || connections["hci1"] != 1 {
+		t.Errorf("got connections %v, want only the pinned device on hci1", connections)
+	}
+}
+
+func TestPoolReconnectStaysOnAdapter(t *testing.T) {
+	pool, adapters := newTestPool(ble.PoolRoundRobin, 2)
+	m := ble.NewSimpleManagerWithAdapter(pool)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	peripheral := newSensorInRange(adapters, "Sensor", "11:22:33:44:55:66")
+	if got := connectedThrough(t, m, testConfig("Sensor", make(chan []byte, 1))); got != "hci0" {
+		t.Fatalf("Sensor connected through %s, want hci0", got)
+	}
+
+	// Round-robin would pick hci1 for the next device
+	peripheral.Drop()
+	nextEvent(t, events, ble.EventDisconnected)
+	if connections := pool.Connections(); connections["hci0"] != 0 {
+		t.Errorf("got connections %v after the link loss, want none on hci0", connections)
+	}
+
+	nextEvent(t, events, ble.EventConnected)
+	if device := m.GetConnectedDevices()["Sensor"]; device == nil || device.AdapterID != "hci0" {
+		t.Error("Sensor did not reconnect through hci0")
+	}
+	if adapterID, ok := pool.AdapterOf(peripheral.Address); !ok || adapterID != "hci0" {
+		t.Errorf("pool places Sensor on %q, want hci0", adapterID)
+	}
+}
+
+func TestPoolStopScanStopsAllAdapters(t *testing.T) {
+	pool, adapters := newTestPool(ble.PoolRoundRobin, 2)
+	if err := pool.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	newTestSensor(adapters[0], "Near hci0", "11:22:33:44:55:01")
+	newTestSensor(adapters[1], "Near hci1", "11:22:33:44:55:02")
+
+	for round := 0; round < 2; round++ {
+		// The pool never calls back concurrently
+		seen := make(map[string]bool)
+		both := make(chan struct{})
+		var once sync.Once
+		done := make(chan error, 1)
+		go func() {
+			done <- pool.Scan(func(result bluetooth.ScanResult) {
+				seen[result.LocalName()] = true
+				if len(seen) == 2 {
+					once.Do(func() { close(both) })
+				}
+			})
+		}()
+
+		select {
+		case <-both:
+		case <-time.After(testTimeout):
+			t.Fatalf("round %d: the scan did not report devices of both adapters", round)
+		}
+		if err := pool.StopScan(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("round %d: scan failed: %v", round, err)
+			}
+		case <-time.After(testTimeout):
+			t.Fatalf("round %d: Scan did not return after StopScan", round)
+		}
+
+		for i, adapter := range adapters {
+			if err := adapter.StopScan(); err == nil {
+				t.Errorf("round %d: adapter hci%d was still scanning", round, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package ble
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy controls how a disconnected device is reconnected.
+// The zero value retries every DefaultReconnectDelay forever.
+type ReconnectPolicy struct {
+	// InitialDelay is the pause before the first attempt (default DefaultReconnectDelay)
+	InitialDelay time.Duration
+	// Multiplier scales the delay after every failed attempt; values <= 1 keep it constant
+	Multiplier float64
+	// MaxDelay caps the delay between attempts, including jitter (0 means no cap)
+	MaxDelay time.Duration
+	// Jitter randomly varies each delay by up to this fraction, e.g. 0.2 for ±20%
+	Jitter float64
+	// MaxAttempts is the number of attempts before giving up (0 means retry forever)
+	MaxAttempts int
+	// OnGiveUp, if set, is called once MaxAttempts attempts have failed
+	OnGiveUp func(deviceName string, attempts int, lastErr error)
+}
+
+// ExponentialBackoff returns a policy that doubles the delay after every failed
+// attempt, starting at initialDelay and capped at maxDelay, with ±20% jitter.
+func ExponentialBackoff(initialDelay, maxDelay time.Duration) ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialDelay: initialDelay,
+		Multiplier:   2,
+		MaxDelay:     maxDelay,
+		Jitter:       0.2,
+	}
+}
+
+// Delay returns the pause before the given attempt, counting from 1
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	delay := float64(p.InitialDelay)
+	if delay <= 0 {
+		delay = float64(DefaultReconnectDelay)
+	}
+
+	if p.Multiplier > 1 && attempt > 1 {
+		delay *= math.Pow(p.Multiplier, float64(attempt-1))
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// exhausted reports whether no further attempt is allowed after the given number of attempts
+func (p ReconnectPolicy) exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
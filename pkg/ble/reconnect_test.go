@@ -0,0 +1,55 @@
+package ble
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ReconnectPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"zero value", ReconnectPolicy{}, 1, DefaultReconnectDelay},
+		{"zero value stays constant", ReconnectPolicy{}, 5, DefaultReconnectDelay},
+		{"initial delay", ReconnectPolicy{InitialDelay: time.Second}, 1, time.Second},
+		{"multiplier", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 2}, 4, 8 * time.Second},
+		{"multiplier of 1 keeps the delay", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 1}, 4, time.Second},
+		{"capped", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 2, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"no overflow", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 10}, 100, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconnectPolicyJitter(t *testing.T) {
+	policy := ReconnectPolicy{InitialDelay: time.Second, Jitter: 0.2, MaxDelay: 1100 * time.Millisecond}
+	for i := 0; i < 1000; i++ {
+		delay := policy.Delay(1)
+		if delay < 800*time.Millisecond || delay > 1100*time.Millisecond {
+			t.Fatalf("Delay(1) = %s, want between 800ms and the 1.1s cap", delay)
+		}
+	}
+}
+
+func TestReconnectPolicyExhausted(t *testing.T) {
+	unlimited := ReconnectPolicy{}
+	if unlimited.exhausted(1000) {
+		t.Error("policy without MaxAttempts gave up")
+	}
+
+	limited := ReconnectPolicy{MaxAttempts: 3}
+	if limited.exhausted(2) {
+		t.Error("gave up after 2 of 3 attempts")
+	}
+	if !limited.exhausted(3) {
+		t.Error("did not give up after 3 of 3 attempts")
+	}
+}
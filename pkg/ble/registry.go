@@ -0,0 +1,182 @@
+package ble
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Binding records which physical device serves a logical device name
+type Binding struct {
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	LocalName string    `json:"local_name,omitempty"` // advertised name when first bound
+	BoundAt   time.Time `json:"bound_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// registryFile is the on-disk format of a Registry
+type registryFile struct {
+	Bindings []Binding `json:"bindings"`
+}
+
+// Registry remembers the address bound to each logical device name and
+// persists the bindings to a JSON file. A manager with a registry binds every
+// device on its first successful connect and afterwards connects to the bound
+// address directly, falling back to scanning for that address.
+type Registry struct {
+	path     string
+	bindings map[string]Binding
+	mu       sync.Mutex
+}
+
+// OpenRegistry loads the registry stored at path. A missing file yields an
+// empty registry that is created on the first change.
+func OpenRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, bindings: make(map[string]Binding)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry: %v", err)
+	}
+
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse registry %s: %v", path, err)
+	}
+	for _, binding := range file.Bindings {
+		r.bindings[binding.Name] = binding
+	}
+	return r, nil
+}
+
+// Bindings returns all bindings sorted by name
+func (r *Registry) Bindings() []Binding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sorted()
+}
+
+// Lookup returns the binding of a logical device name
+func (r *Registry) Lookup(name string) (Binding, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	binding, ok := r.bindings[name]
+	return binding, ok
+}
+
+// Bind binds name to address, or refreshes LastSeen if it is already bound to
+// it. An empty localName keeps the one recorded before.
+func (r *Registry) Bind(name, address, localName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	binding, ok := r.bindings[name]
+	if !ok || !strings.EqualFold(binding.Address, address) {
+		binding = Binding{Name: name, Address: address, BoundAt: now}
+	}
+	if localName != "" {
+		binding.LocalName = localName
+	}
+	binding.LastSeen = now
+
+	r.bindings[name] = binding
+	return r.save()
+}
+
+// Rename moves the binding of oldName to newName
+func (r *Registry) Rename(oldName, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	binding, ok := r.bindings[oldName]
+	if !ok {
+		return fmt.Errorf("no binding for %s", oldName)
+	}
+	if _, exists := r.bindings[newName]; exists {
+		return fmt.Errorf("%s is already bound", newName)
+	}
+
+	delete(r.bindings, oldName)
+	binding.Name = newName
+	r.bindings[newName] = binding
+	return r.save()
+}
+
+// Forget removes the binding of name, so the device is found by scanning again
+func (r *Registry) Forget(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.bindings[name]; !ok {
+		return fmt.Errorf("no binding for %s", name)
+	}
+	delete(r.bindings, name)
+	return r.save()
+}
+
+// sorted returns the bindings sorted by name. r.mu must be held.
+func (r *Registry) sorted() []Binding {
+	bindings := make([]Binding, 0, len(r.bindings))
+	for _, binding := range r.bindings {
+		bindings = append(bindings, binding)
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Name < bindings[j].Name
+	})
+	return bindings
+}
+
+// save writes the registry atomically via a temporary file. r.mu must be held.
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(registryFile{Bindings: r.sorted()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create registry directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write registry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write registry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write registry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write registry: %v", err)
+	}
+	return nil
+}
+
+// parseAddress parses an address as stored in a Binding: a MAC address, or
+// the CoreBluetooth UUID on macOS
+func parseAddress(s string) (bluetooth.Address, error) {
+	var address bluetooth.Address
+	address.Set(strings.ToUpper(s))
+	if !strings.EqualFold(address.String(), s) {
+		return bluetooth.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return address, nil
+}
@@ -0,0 +1,133 @@
+package ble_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+)
+
+func TestRegistryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "devices.json")
+
+	registry, err := ble.OpenRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bindings := registry.Bindings(); len(bindings) != 0 {
+		t.Fatalf("new registry has bindings %v", bindings)
+	}
+	if err := registry.Bind("pen", "C0:1B:05:00:00:01", "COLUMBUS Video Pen"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Bind("desk", "7E:0A:12:00:00:01", "Timeular Tra"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := ble.OpenRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindings := reopened.Bindings()
+	if len(bindings) != 2 || bindings[0].Name != "desk" || bindings[1].Name != "pen" {
+		t.Fatalf("got bindings %v, want desk and pen", bindings)
+	}
+	pen := bindings[1]
+	if pen.Address != "C0:1B:05:00:00:01" || pen.LocalName != "COLUMBUS Video Pen" || pen.BoundAt.IsZero() {
+		t.Errorf("got binding %+v, want the pen as bound", pen)
+	}
+
+	// Seeing the same device again keeps the binding, another one replaces it
+	if err := reopened.Bind("pen", "c0:1b:05:00:00:01", ""); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := reopened.Lookup("pen"); !again.BoundAt.Equal(pen.BoundAt) || again.LocalName != pen.LocalName {
+		t.Errorf("rebinding the same address changed the binding to %+v", again)
+	}
+	if err := reopened.Bind("pen", "C0:1B:05:00:00:02", ""); err != nil {
+		t.Fatal(err)
+	}
+	if replaced, _ := reopened.Lookup("pen"); replaced.Address != "C0:1B:05:00:00:02" || replaced.LocalName != "" {
+		t.Errorf("got binding %+v, want a new binding to C0:1B:05:00:00:02", replaced)
+	}
+}
+
+func TestRegistryRenameAndForget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	registry, err := ble.OpenRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Bind("pen", "C0:1B:05:00:00:01", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Bind("desk", "7E:0A:12:00:00:01", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registry.Rename("pen", "desk"); err == nil {
+		t.Error("renamed onto an existing binding")
+	}
+	if err := registry.Rename("globe", "pen"); err == nil {
+		t.Error("renamed a missing binding")
+	}
+	if err := registry.Rename("pen", "kitchen pen"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Forget("desk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Forget("desk"); err == nil {
+		t.Error("forgot a missing binding")
+	}
+
+	reopened, err := ble.OpenRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bindings := reopened.Bindings()
+	if len(bindings) != 1 || bindings[0].Name != "kitchen pen" || bindings[0].Address != "C0:1B:05:00:00:01" {
+		t.Errorf("got bindings %v, want only kitchen pen", bindings)
+	}
+}
+
+func TestOpenRegistryRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	if err := os.WriteFile(path, []byte("{bindings"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ble.OpenRegistry(path); err == nil {
+		t.Error("opened a malformed registry")
+	}
+}
+
+func TestRegistryConnectsBoundDevice(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, _ := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+	registry, err := ble.OpenRegistry(filepath.Join(t.TempDir(), "devices.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := ble.NewSimpleManagerWithAdapter(adapter)
+	first.SetRegistry(registry)
+	connectAll(t, first, testConfig("Sensor", make(chan []byte, 1)))
+	first.Close()
+
+	binding, ok := registry.Lookup("Sensor")
+	if !ok || binding.Address != peripheral.Address.String() || binding.LocalName != "Sensor" {
+		t.Fatalf("got binding %+v, want Sensor bound to %s", binding, peripheral.Address.String())
+	}
+
+	// A bound device is connected by address, without advertising under its name
+	peripheral.SetName("Renamed")
+	second := ble.NewSimpleManagerWithAdapter(adapter)
+	defer second.Close()
+	second.SetRegistry(registry)
+	connectAll(t, second, testConfig("Sensor", make(chan []byte, 1)))
+	if !peripheral.IsConnected() {
+		t.Error("bound device is not connected")
+	}
+}
@@ -0,0 +1,145 @@
+package ble
+
+import (
+	"fmt"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// DefaultReadInterval is the polling interval of ModeRead subscriptions without an Interval
+const DefaultReadInterval = time.Second
+
+// maxValueSize is the largest attribute value a characteristic can hold
+const maxValueSize = 512
+
+// SubscriptionMode selects how values of a characteristic are received
+type SubscriptionMode int
+
+const (
+	// ModeNotify subscribes to notifications (the default)
+	ModeNotify SubscriptionMode = iota
+	// ModeIndicate subscribes to indications. The platform stack picks
+	// notifications or indications from the characteristic properties and
+	// acknowledges indications itself, so this differs from ModeNotify only
+	// in intent.
+	ModeIndicate
+	// ModeRead reads the characteristic every Interval
+	ModeRead
+	// ModePoll hands the characteristic to Reader, which reads it on its own schedule
+	ModePoll
+)
+
+func (m SubscriptionMode) String() string {
+	switch m {
+	case ModeNotify:
+		return "notify"
+	case ModeIndicate:
+		return "indicate"
+	case ModeRead:
+		return "read"
+	case ModePoll:
+		return "poll"
+	default:
+		return "unknown"
+	}
+}
+
+// Subscription declares one characteristic of a device to receive values from
+type Subscription struct {
+	ServiceUUID        bluetooth.UUID
+	CharacteristicUUID bluetooth.UUID
+	Mode               SubscriptionMode
+	Interval           time.Duration // ModeRead only, DefaultReadInterval if zero
+	Handler            func(deviceName string, data []byte) error
+
+	// Reader receives the characteristic of a ModePoll subscription once the
+	// device is connected, and nil once it has disconnected. It must not block.
+	Reader func(deviceName string, characteristic Characteristic)
+}
+
+// subscriptions returns the subscriptions of config. The legacy
+// ServiceUUID/CharacteristicUUID/NotificationHandler fields, if set, come first
+// as a ModeNotify subscription.
+func (c DeviceConfig) subscriptions() []Subscription {
+	var subs []Subscription
+	if c.CharacteristicUUID != (bluetooth.UUID{}) {
+		subs = append(subs, Subscription{
+			ServiceUUID:        c.ServiceUUID,
+			CharacteristicUUID: c.CharacteristicUUID,
+			Mode:               ModeNotify,
+			Handler:            c.NotificationHandler,
+		})
+	}
+	return append(subs, c.Subscriptions...)
+}
+
+// characteristicKey identifies a characteristic by service and characteristic UUID
+type characteristicKey struct {
+	service        bluetooth.UUID
+	characteristic bluetooth.UUID
+}
+
+// activeSubscription is a subscription set up on a connection
+type activeSubscription struct {
+	Subscription
+	characteristic Characteristic
+	channel        chan []byte      // nil unless notify or indicate
+	responses      *responseWaiters // nil unless notify or indicate
+}
+
+// notifies reports whether the subscription receives values through notifications
+func (s Subscription) notifies() bool {
+	return s.Mode == ModeNotify || s.Mode == ModeIndicate
+}
+
+// key returns the characteristic key of the subscription
+func (s Subscription) key() characteristicKey {
+	return characteristicKey{s.ServiceUUID, s.CharacteristicUUID}
+}
+
+// discoverSubscriptions finds the characteristics of all subscriptions on conn,
+// discovering each service and its characteristics once
+func discoverSubscriptions(conn Connection, subs []Subscription) ([]activeSubscription, error) {
+	var serviceUUIDs []bluetooth.UUID
+	charUUIDs := make(map[bluetooth.UUID][]bluetooth.UUID)
+	seen := make(map[characteristicKey]bool)
+	for _, sub := range subs {
+		key := sub.key()
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate subscription to characteristic %s", sub.CharacteristicUUID.String())
+		}
+		seen[key] = true
+
+		if _, ok := charUUIDs[sub.ServiceUUID]; !ok {
+			serviceUUIDs = append(serviceUUIDs, sub.ServiceUUID)
+		}
+		charUUIDs[sub.ServiceUUID] = append(charUUIDs[sub.ServiceUUID], sub.CharacteristicUUID)
+	}
+
+	services, err := conn.DiscoverServices(serviceUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("service discovery failed: %v", err)
+	}
+
+	characteristics := make(map[characteristicKey]Characteristic)
+	for _, service := range services {
+		chars, err := service.DiscoverCharacteristics(charUUIDs[service.UUID()])
+		if err != nil {
+			return nil, fmt.Errorf("characteristic discovery failed for service %s: %v", service.UUID().String(), err)
+		}
+		for _, char := range chars {
+			characteristics[characteristicKey{service.UUID(), char.UUID()}] = char
+		}
+	}
+
+	active := make([]activeSubscription, len(subs))
+	for i, sub := range subs {
+		char, ok := characteristics[sub.key()]
+		if !ok {
+			return nil, fmt.Errorf("required characteristic %s not found", sub.CharacteristicUUID.String())
+		}
+		active[i] = activeSubscription{Subscription: sub, characteristic: char}
+	}
+	return active, nil
+}
@@ -0,0 +1,128 @@
+package ble_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// testBatteryServiceUUID holds a second notifying characteristic of the multi-characteristic sensor
+var testBatteryServiceUUID = bluetooth.New16BitUUID(0x180F)
+
+// forward returns a subscription handler that sends values to received
+func forward(received chan<- []byte) func(deviceName string, data []byte) error {
+	return func(deviceName string, data []byte) error {
+		received <- data
+		return nil
+	}
+}
+
+func TestMultipleSubscriptions(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral := bletest.NewPeripheral("Sensor", bletest.MustParseAddress("11:22:33:44:55:66"))
+	heartRate := peripheral.AddService(testServiceUUID).AddCharacteristic(testCharacteristicUUID)
+	battery := peripheral.AddService(testBatteryServiceUUID).AddCharacteristic(testPollUUID)
+	adapter.AddPeripheral(peripheral)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	events, unsubscribe := m.Subscribe(0)
+	defer unsubscribe()
+
+	// The legacy fields and Subscriptions share one connection
+	heartRates := make(chan []byte, 4)
+	levels := make(chan []byte, 4)
+	config := testConfig("Sensor", heartRates)
+	config.Subscriptions = []ble.Subscription{{
+		ServiceUUID:        testBatteryServiceUUID,
+		CharacteristicUUID: testPollUUID,
+		Mode:               ble.ModeIndicate,
+		Handler:            forward(levels),
+	}}
+	connectAll(t, m, config)
+	nextEvent(t, events, ble.EventConnected)
+
+	for round := 0; round < 2; round++ {
+		if !heartRate.IsSubscribed() || !battery.IsSubscribed() {
+			t.Fatalf("round %d: subscribed %t and %t, want both characteristics", round, heartRate.IsSubscribed(), battery.IsSubscribed())
+		}
+
+		// Each value reaches the handler of its own characteristic only
+		if err := battery.Notify([]byte{90}); err != nil {
+			t.Fatal(err)
+		}
+		if err := heartRate.Notify([]byte{60}); err != nil {
+			t.Fatal(err)
+		}
+		if data := receive(t, levels); !bytes.Equal(data, []byte{90}) {
+			t.Errorf("round %d: battery handler got %v, want [90]", round, data)
+		}
+		if data := receive(t, heartRates); !bytes.Equal(data, []byte{60}) {
+			t.Errorf("round %d: heart rate handler got %v, want [60]", round, data)
+		}
+		if len(levels) != 0 || len(heartRates) != 0 {
+			t.Errorf("round %d: a value reached both handlers", round)
+		}
+
+		// Both subscriptions are restored on reconnect
+		peripheral.Drop()
+		nextEvent(t, events, ble.EventConnected)
+	}
+}
+
+func TestModeRead(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	peripheral, characteristic := newTestSensor(adapter, "Sensor", "11:22:33:44:55:66")
+	characteristic.SetValue([]byte{1})
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	values := make(chan []byte, 16)
+	connectAll(t, m, ble.DeviceConfig{
+		Name: "Sensor",
+		Subscriptions: []ble.Subscription{{
+			ServiceUUID:        testServiceUUID,
+			CharacteristicUUID: testCharacteristicUUID,
+			Mode:               ble.ModeRead,
+			Interval:           5 * time.Millisecond,
+			Handler:            forward(values),
+		}},
+	})
+
+	// The characteristic is read, not subscribed to
+	if characteristic.IsSubscribed() {
+		t.Error("ModeRead enabled notifications")
+	}
+	if data := receive(t, values); !bytes.Equal(data, []byte{1}) {
+		t.Errorf("got %v, want [1]", data)
+	}
+
+	// Every read is delivered, so a new value shows up within a few intervals
+	characteristic.SetValue([]byte{2})
+	deadline := time.Now().Add(testTimeout)
+	for !bytes.Equal(receive(t, values), []byte{2}) {
+		if time.Now().After(deadline) {
+			t.Fatal("the new value was not read")
+		}
+	}
+
+	// Reads stop with the connection
+	if err := m.Disconnect("Sensor"); err != nil {
+		t.Fatal(err)
+	}
+	awaitDisconnected(t, peripheral)
+	// A read in flight during the disconnect may still be delivered
+	time.Sleep(10 * time.Millisecond)
+	for len(values) > 0 {
+		<-values
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := len(values); n != 0 {
+		t.Errorf("got %d values after the disconnect", n)
+	}
+}
@@ -0,0 +1,246 @@
+package ble
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/internal/logging"
+	"tinygo.org/x/bluetooth"
+)
+
+// tinyGoAdapter implements Adapter on top of a tinygo bluetooth.Adapter
+type tinyGoAdapter struct {
+	linkHandlers
+	adapter   *bluetooth.Adapter
+	bluezPath string // object path of the adapter, e.g. /org/bluez/hci0 (Linux only)
+}
+
+// NewTinyGoAdapter wraps a tinygo bluetooth adapter, e.g. bluetooth.DefaultAdapter
+func NewTinyGoAdapter(adapter *bluetooth.Adapter) Adapter {
+	return &tinyGoAdapter{adapter: adapter}
+}
+
+func (a *tinyGoAdapter) Enable() error {
+	if err := a.adapter.Enable(); err != nil {
+		return err
+	}
+	if err := a.resolveAdapterPath(); err != nil {
+		return err
+	}
+
+	// Give macOS time to initialize
+	time.Sleep(2 * time.Second)
+
+	// Must be set before adapter.Connect() calls per tinygo/bluetooth docs
+	a.adapter.SetConnectHandler(func(device bluetooth.Device, connected bool) {
+		if !connected {
+			a.stopWatchdog(device.Address)
+			a.notifyDisconnect(device.Address, nil)
+			return
+		}
+		a.notifyConnect(device.Address, true)
+	})
+	return nil
+}
+
+func (a *tinyGoAdapter) Scan(callback func(result bluetooth.ScanResult)) error {
+	return a.adapter.Scan(func(_ *bluetooth.Adapter, result bluetooth.ScanResult) {
+		callback(result)
+	})
+}
+
+func (a *tinyGoAdapter) StopScan() error {
+	return a.adapter.StopScan()
+}
+
+func (a *tinyGoAdapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (Connection, error) {
+	// Brief delay after stopping scan (important for macOS)
+	time.Sleep(500 * time.Millisecond)
+
+	device, err := a.adapter.Connect(address, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a platform-specific watchdog that monitors the connection
+	// via D-Bus on Linux (where SetConnectHandler doesn't fire).
+	cancel, err := a.watch(address, func(err error) {
+		a.lost(address, err)
+	})
+	if err != nil {
+		device.Disconnect()
+		return nil, err
+	}
+	a.setWatchdog(address, cancel)
+
+	return &tinyGoConnection{adapter: a, device: device}, nil
+}
+
+// linkHandlers holds the connection callbacks and the disconnect watchdogs of
+// the adapters that drive a real radio. Its zero value is ready to use.
+type linkHandlers struct {
+	connectHandler    func(address bluetooth.Address, connected bool)
+	disconnectHandler func(address bluetooth.Address, err error)
+	watchdogs         map[string]func()
+	logger            atomic.Pointer[slog.Logger]
+	mu                sync.Mutex
+}
+
+// SetLogger sets the logger used by the connection watchdog
+func (h *linkHandlers) SetLogger(logger *slog.Logger) {
+	h.logger.Store(logging.OrDiscard(logger))
+}
+
+func (h *linkHandlers) SetConnectHandler(handler func(address bluetooth.Address, connected bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connectHandler = handler
+}
+
+// SetDisconnectHandler implements DisconnectReporter
+func (h *linkHandlers) SetDisconnectHandler(handler func(address bluetooth.Address, err error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disconnectHandler = handler
+}
+
+// log returns the logger set with SetLogger
+func (h *linkHandlers) log() *slog.Logger {
+	return logging.OrDiscard(h.logger.Load())
+}
+
+// notifyConnect forwards a connection state change to the registered handler
+func (h *linkHandlers) notifyConnect(address bluetooth.Address, connected bool) {
+	h.mu.Lock()
+	handler := h.connectHandler
+	h.mu.Unlock()
+
+	if handler != nil {
+		handler(address, connected)
+	}
+}
+
+// notifyDisconnect reports an ended connection and why, falling back to the
+// connect handler if no disconnect handler is registered
+func (h *linkHandlers) notifyDisconnect(address bluetooth.Address, err error) {
+	h.mu.Lock()
+	handler := h.disconnectHandler
+	h.mu.Unlock()
+
+	if handler == nil {
+		h.notifyConnect(address, false)
+		return
+	}
+	handler(address, err)
+}
+
+// lost handles a disconnect reported by the watchdog of the given device
+func (h *linkHandlers) lost(address bluetooth.Address, err error) {
+	if err != nil {
+		h.log().Warn("watchdog failed, treating device as disconnected", "address", address.String(),
+			"phase", "watchdog", "error", err)
+	}
+	h.stopWatchdog(address)
+	h.notifyDisconnect(address, err)
+}
+
+// setWatchdog registers the cancel function of a device's watchdog,
+// cancelling the one it replaces
+func (h *linkHandlers) setWatchdog(address bluetooth.Address, cancel func()) {
+	h.mu.Lock()
+	previous, ok := h.watchdogs[address.String()]
+	if h.watchdogs == nil {
+		h.watchdogs = make(map[string]func())
+	}
+	h.watchdogs[address.String()] = cancel
+	h.mu.Unlock()
+
+	if ok {
+		previous()
+	}
+}
+
+// stopWatchdog cancels the watchdog of the given device, if any
+func (h *linkHandlers) stopWatchdog(address bluetooth.Address) {
+	h.mu.Lock()
+	cancel, ok := h.watchdogs[address.String()]
+	delete(h.watchdogs, address.String())
+	h.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// tinyGoConnection implements Connection on top of a tinygo bluetooth.Device
+type tinyGoConnection struct {
+	adapter *tinyGoAdapter
+	device  bluetooth.Device
+}
+
+func (c *tinyGoConnection) Address() bluetooth.Address {
+	return c.device.Address
+}
+
+func (c *tinyGoConnection) DiscoverServices(uuids []bluetooth.UUID) ([]Service, error) {
+	services, err := c.device.DiscoverServices(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Service, len(services))
+	for i := range services {
+		result[i] = tinyGoService{service: services[i]}
+	}
+	return result, nil
+}
+
+func (c *tinyGoConnection) Disconnect() error {
+	c.adapter.stopWatchdog(c.device.Address)
+	return c.device.Disconnect()
+}
+
+// tinyGoService implements Service on top of a tinygo bluetooth.DeviceService
+type tinyGoService struct {
+	service bluetooth.DeviceService
+}
+
+func (s tinyGoService) UUID() bluetooth.UUID {
+	return s.service.UUID()
+}
+
+func (s tinyGoService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]Characteristic, error) {
+	characteristics, err := s.service.DiscoverCharacteristics(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Characteristic, len(characteristics))
+	for i := range characteristics {
+		result[i] = tinyGoCharacteristic{characteristic: characteristics[i]}
+	}
+	return result, nil
+}
+
+// tinyGoCharacteristic implements Characteristic on top of a tinygo bluetooth.DeviceCharacteristic
+type tinyGoCharacteristic struct {
+	characteristic bluetooth.DeviceCharacteristic
+}
+
+func (c tinyGoCharacteristic) UUID() bluetooth.UUID {
+	return c.characteristic.UUID()
+}
+
+func (c tinyGoCharacteristic) EnableNotifications(callback func(buf []byte)) error {
+	return c.characteristic.EnableNotifications(callback)
+}
+
+func (c tinyGoCharacteristic) Read(data []byte) (int, error) {
+	return c.characteristic.Read(data)
+}
+
+func (c tinyGoCharacteristic) WriteWithoutResponse(p []byte) (int, error) {
+	return c.characteristic.WriteWithoutResponse(p)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package ble
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"tinygo.org/x/bluetooth"
+)
+
+// bluezObjects returns all objects BlueZ exports with their interfaces and properties
+func bluezObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to D-Bus: %v", err)
+	}
+	return managedObjects(conn)
+}
+
+// resolveAdapterPath finds the BlueZ object path of the enabled adapter by its address
+func (a *tinyGoAdapter) resolveAdapterPath() error {
+	address, err := a.adapter.Address()
+	if err != nil {
+		return fmt.Errorf("failed to read adapter address: %v", err)
+	}
+
+	objects, err := bluezObjects()
+	if err != nil {
+		return err
+	}
+	for path, interfaces := range objects {
+		properties, ok := interfaces["org.bluez.Adapter1"]
+		if !ok {
+			continue
+		}
+		if addr, ok := properties["Address"].Value().(string); ok && strings.EqualFold(addr, address.String()) {
+			a.bluezPath = string(path)
+			return nil
+		}
+	}
+	return fmt.Errorf("no BlueZ adapter with address %s", address.String())
+}
+
+// devicePath returns the BlueZ object path of a device on this adapter,
+// e.g. /org/bluez/hci1/dev_AA_BB_CC_DD_EE_FF
+func (a *tinyGoAdapter) devicePath(address bluetooth.Address) dbus.ObjectPath {
+	return dbus.ObjectPath(a.bluezPath + "/dev_" + strings.ReplaceAll(address.MAC.String(), ":", "_"))
+}
+
+// watch monitors the connection to a device via D-Bus, since BlueZ does not
+// fire the connect handler of tinygo bluetooth on disconnects
+func (a *tinyGoAdapter) watch(address bluetooth.Address, onDisconnect func(err error)) (cancel func(), err error) {
+	return watchdog.watch(a.devicePath(address), onDisconnect)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package ble
+
+import "tinygo.org/x/bluetooth"
+
+// resolveAdapterPath is a no-op on non-Linux platforms
+func (a *tinyGoAdapter) resolveAdapterPath() error {
+	return nil
+}
+
+// watch is a no-op on non-Linux platforms where the adapter's
+// SetConnectHandler already provides disconnect notifications.
+func (a *tinyGoAdapter) watch(address bluetooth.Address, onDisconnect func(err error)) (cancel func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,30 @@
+//go:build linux
+
+package ble
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// RSSI returns the signal strength BlueZ last measured for the device. BlueZ
+// takes it from advertisements, so it is only updated while the peripheral
+// keeps advertising during the connection.
+func (c *tinyGoConnection) RSSI() (int16, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to D-Bus: %v", err)
+	}
+
+	variant, err := conn.Object("org.bluez", c.adapter.devicePath(c.device.Address)).GetProperty("org.bluez.Device1.RSSI")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read RSSI: %v", err)
+	}
+	rssi, ok := variant.Value().(int16)
+	if !ok {
+		return 0, errors.New("RSSI not available")
+	}
+	return rssi, nil
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package ble
+
+// Write on Linux goes through the same BlueZ WriteValue call as
+// WriteWithoutResponse: without a "type" option, BlueZ sends a write request
+// when the characteristic supports it and the call returns once it is
+// acknowledged.
+func (c tinyGoCharacteristic) Write(p []byte) (int, error) {
+	return c.characteristic.WriteWithoutResponse(p)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package ble
+
+func (c tinyGoCharacteristic) Write(p []byte) (int, error) {
+	return c.characteristic.Write(p)
+}
@@ -4,80 +4,135 @@ package ble
 
 import (
 	"fmt"
-	"strings"
+	"sync"
 
 	"github.com/godbus/dbus/v5"
-	"tinygo.org/x/bluetooth"
 )
 
-// watchConnection monitors a BLE device's Connected property via D-Bus.
-// When the property changes to false, it calls onDisconnect with the
-// bluetooth.Device. The returned cancel function stops the watcher.
-func watchConnection(device *bluetooth.Device, addr bluetooth.Address, onDisconnect func(bluetooth.Device)) (cancel func()) {
-	done := make(chan struct{})
-
-	go func() {
-		conn, err := dbus.SystemBus()
-		if err != nil {
-			fmt.Printf("⚠️  watchdog: cannot connect to D-Bus: %v\n", err)
-			return
+// watchdog dispatches BlueZ property changes to the watched devices of all
+// adapters from a single D-Bus signal subscription. Each device adds a match
+// rule for its own object path, so the bus only forwards relevant signals.
+var watchdog = &signalDispatcher{watches: make(map[dbus.ObjectPath]*watch)}
+
+type signalDispatcher struct {
+	conn    *dbus.Conn
+	watches map[dbus.ObjectPath]*watch
+	mu      sync.Mutex
+}
+
+// watch is one watched device. onDisconnect is called at most once, with nil
+// when BlueZ reports the device disconnected or with ErrWatchdogLost.
+type watch struct {
+	onDisconnect func(err error)
+	once         sync.Once
+}
+
+func (w *watch) disconnected(err error) {
+	w.once.Do(func() { w.onDisconnect(err) })
+}
+
+// matchOptions selects PropertiesChanged signals of the Device1 interface at path
+func matchOptions(path dbus.ObjectPath) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, "org.bluez.Device1"),
+	}
+}
+
+// watch monitors the Connected property of the device at path until the
+// returned cancel function is called. A device can only be watched once;
+// watching it again replaces the previous watch.
+func (d *signalDispatcher) watch(path dbus.ObjectPath, onDisconnect func(err error)) (cancel func(), err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.start(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := d.watches[path]; !ok {
+		if err := d.conn.AddMatchSignal(matchOptions(path)...); err != nil {
+			return nil, fmt.Errorf("failed to add D-Bus match rule for %s: %v", path, err)
 		}
+	}
+	w := &watch{onDisconnect: onDisconnect}
+	d.watches[path] = w
+
+	return func() { d.unwatch(path, w) }, nil
+}
+
+// unwatch removes w, unless it has been replaced in the meantime
+func (d *signalDispatcher) unwatch(path dbus.ObjectPath, w *watch) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-		// Build the BlueZ device path from the MAC address.
-		// Format: /org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF
-		mac := addr.MAC.String()
-		devPath := dbus.ObjectPath("/org/bluez/hci0/dev_" + strings.Replace(mac, ":", "_", -1))
-
-		signal := make(chan *dbus.Signal, 1)
-		conn.Signal(signal)
-		matchOpts := []dbus.MatchOption{dbus.WithMatchInterface("org.freedesktop.DBus.Properties")}
-		conn.AddMatchSignal(matchOpts...)
-
-		defer func() {
-			conn.RemoveSignal(signal)
-			conn.RemoveMatchSignal(matchOpts...)
-		}()
-
-		for {
-			select {
-			case <-done:
-				return
-			case sig := <-signal:
-				if sig == nil {
-					return
-				}
-				if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" {
-					continue
-				}
-				if sig.Path != devPath {
-					continue
-				}
-				if len(sig.Body) < 2 {
-					continue
-				}
-				iface, ok := sig.Body[0].(string)
-				if !ok || iface != "org.bluez.Device1" {
-					continue
-				}
-				changes, ok := sig.Body[1].(map[string]dbus.Variant)
-				if !ok {
-					continue
-				}
-				if connected, ok := changes["Connected"]; ok {
-					if val, ok := connected.Value().(bool); ok && !val {
-						onDisconnect(*device)
-						return
-					}
-				}
-			}
+	if d.watches[path] != w {
+		return
+	}
+	delete(d.watches, path)
+	if d.conn != nil {
+		d.conn.RemoveMatchSignal(matchOptions(path)...)
+	}
+}
+
+// start connects to the system bus and starts dispatching. d.mu must be held.
+func (d *signalDispatcher) start() error {
+	if d.conn != nil {
+		return nil
+	}
+
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return fmt.Errorf("watchdog cannot connect to D-Bus: %v", err)
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	d.conn = conn
+	go d.dispatch(conn, signals)
+	return nil
+}
+
+// dispatch delivers disconnects until the bus connection closes, then
+// reports every watched device as lost since it can no longer be supervised
+func (d *signalDispatcher) dispatch(conn *dbus.Conn, signals chan *dbus.Signal) {
+	for sig := range signals {
+		// The shared connection also carries signals subscribed to by the
+		// bluetooth stack itself
+		if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+			continue
+		}
+		if iface, ok := sig.Body[0].(string); !ok || iface != "org.bluez.Device1" {
+			continue
+		}
+		changes, ok := sig.Body[1].(map[string]dbus.Variant)
+		if !ok {
+			continue
+		}
+		if connected, ok := changes["Connected"].Value().(bool); !ok || connected {
+			continue
 		}
-	}()
 
-	return func() {
-		select {
-		case <-done:
-		default:
-			close(done)
+		d.mu.Lock()
+		w, ok := d.watches[sig.Path]
+		d.mu.Unlock()
+		if ok {
+			// A slow disconnect handler must not hold up the other devices
+			go w.disconnected(nil)
 		}
 	}
+
+	d.mu.Lock()
+	lost := d.watches
+	d.watches = make(map[dbus.ObjectPath]*watch)
+	if d.conn == conn {
+		d.conn = nil
+	}
+	d.mu.Unlock()
+
+	for _, w := range lost {
+		go w.disconnected(ErrWatchdogLost)
+	}
 }
@@ -0,0 +1,239 @@
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// DefaultRequestTimeout is how long Request waits for a response without a Timeout
+const DefaultRequestTimeout = 5 * time.Second
+
+// ErrNotConnected is returned when writing to a device that is not connected
+var ErrNotConnected = errors.New("ble: device not connected")
+
+// Request describes a command written to a device and the notification answering it
+type Request struct {
+	ServiceUUID        bluetooth.UUID
+	CharacteristicUUID bluetooth.UUID // characteristic the command is written to
+	Data               []byte
+	WithoutResponse    bool // use a write command instead of a write request
+
+	// ResponseServiceUUID defaults to ServiceUUID. The response characteristic
+	// must be a notify or indicate subscription of the device.
+	ResponseServiceUUID        bluetooth.UUID
+	ResponseCharacteristicUUID bluetooth.UUID
+
+	Match   func(data []byte) bool // nil accepts the first notification
+	Timeout time.Duration          // DefaultRequestTimeout if zero
+}
+
+// Write writes data to a characteristic and waits for the peripheral to
+// acknowledge it. On Linux, BlueZ picks the write type from the
+// characteristic properties, so Write and WriteWithoutResponse behave alike.
+func (d *SimpleDevice) Write(serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	char, err := d.characteristic(characteristicKey{serviceUUID, characteristicUUID})
+	if err != nil {
+		return err
+	}
+	if _, err := char.Write(data); err != nil {
+		return fmt.Errorf("write to %s failed: %v", characteristicUUID.String(), err)
+	}
+	return nil
+}
+
+// WriteWithoutResponse writes data to a characteristic without waiting for an acknowledgement
+func (d *SimpleDevice) WriteWithoutResponse(serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	char, err := d.characteristic(characteristicKey{serviceUUID, characteristicUUID})
+	if err != nil {
+		return err
+	}
+	if _, err := char.WriteWithoutResponse(data); err != nil {
+		return fmt.Errorf("write to %s failed: %v", characteristicUUID.String(), err)
+	}
+	return nil
+}
+
+// Request writes req.Data and returns the first notification of the response
+// characteristic accepted by req.Match. The response is not passed on to the
+// subscription handler.
+func (d *SimpleDevice) Request(ctx context.Context, req Request) ([]byte, error) {
+	responseKey := characteristicKey{req.ResponseServiceUUID, req.ResponseCharacteristicUUID}
+	if responseKey.service == (bluetooth.UUID{}) {
+		responseKey.service = req.ServiceUUID
+	}
+	waiters, ok := d.responses[responseKey]
+	if !ok {
+		return nil, fmt.Errorf("no notify subscription for response characteristic %s", responseKey.characteristic.String())
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Register before writing so a fast response is not missed
+	waiter := waiters.add(req.Match)
+	defer waiters.remove(waiter)
+
+	write := d.Write
+	if req.WithoutResponse {
+		write = d.WriteWithoutResponse
+	}
+	if err := write(req.ServiceUUID, req.CharacteristicUUID, req.Data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case data := <-waiter.ch:
+		return data, nil
+	case <-d.done:
+		return nil, ErrConnectionLost
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no response from %s within %v: %w", d.Name, timeout, ctx.Err())
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// characteristic returns the characteristic for key, discovering it on first use
+func (d *SimpleDevice) characteristic(key characteristicKey) (Characteristic, error) {
+	select {
+	case <-d.done:
+		return nil, fmt.Errorf("device %s: %w", d.Name, ErrNotConnected)
+	default:
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if char, ok := d.characteristics[key]; ok {
+		return char, nil
+	}
+
+	services, err := d.Connection.DiscoverServices([]bluetooth.UUID{key.service})
+	if err != nil {
+		return nil, fmt.Errorf("service discovery failed: %v", err)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("service %s not found", key.service.String())
+	}
+	chars, err := services[0].DiscoverCharacteristics([]bluetooth.UUID{key.characteristic})
+	if err != nil {
+		return nil, fmt.Errorf("characteristic discovery failed: %v", err)
+	}
+	if len(chars) == 0 {
+		return nil, fmt.Errorf("characteristic %s not found", key.characteristic.String())
+	}
+
+	d.characteristics[key] = chars[0]
+	return chars[0], nil
+}
+
+// responseWaiters hands notifications of one characteristic to pending requests
+type responseWaiters struct {
+	mu      sync.Mutex
+	waiters []*responseWaiter
+}
+
+type responseWaiter struct {
+	match func(data []byte) bool
+	ch    chan []byte
+}
+
+func (w *responseWaiters) add(match func(data []byte) bool) *responseWaiter {
+	waiter := &responseWaiter{match: match, ch: make(chan []byte, 1)}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.waiters = append(w.waiters, waiter)
+	return waiter
+}
+
+func (w *responseWaiters) remove(waiter *responseWaiter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, candidate := range w.waiters {
+		if candidate == waiter {
+			w.waiters = append(w.waiters[:i], w.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// deliver hands data to the oldest matching waiter and reports whether one took it
+func (w *responseWaiters) deliver(data []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, waiter := range w.waiters {
+		if waiter.match == nil || waiter.match(data) {
+			waiter.ch <- data
+			w.waiters = append(w.waiters[:i], w.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// device returns the connected device with the given name
+func (m *SimpleManager) device(deviceName string) (*SimpleDevice, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	device, ok := m.connected[deviceName]
+	if !ok {
+		return nil, fmt.Errorf("device %s: %w", deviceName, ErrNotConnected)
+	}
+	return device, nil
+}
+
+// Write writes data to a characteristic of a connected device, see SimpleDevice.Write
+func (m *SimpleManager) Write(deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	device, err := m.device(deviceName)
+	if err != nil {
+		return err
+	}
+	return device.Write(serviceUUID, characteristicUUID, data)
+}
+
+// WriteWithoutResponse writes data to a characteristic of a connected device without acknowledgement
+func (m *SimpleManager) WriteWithoutResponse(deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	device, err := m.device(deviceName)
+	if err != nil {
+		return err
+	}
+	return device.WriteWithoutResponse(serviceUUID, characteristicUUID, data)
+}
+
+// Request sends a command to a connected device and waits for its response, see SimpleDevice.Request
+func (m *SimpleManager) Request(ctx context.Context, deviceName string, req Request) ([]byte, error) {
+	device, err := m.device(deviceName)
+	if err != nil {
+		return nil, err
+	}
+	return device.Request(ctx, req)
+}
+
+// Write writes data to a characteristic of a connected device
+func (m *Manager) Write(deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	return m.simpleManager.Write(deviceName, serviceUUID, characteristicUUID, data)
+}
+
+// WriteWithoutResponse writes data to a characteristic of a connected device without acknowledgement
+func (m *Manager) WriteWithoutResponse(deviceName string, serviceUUID, characteristicUUID bluetooth.UUID, data []byte) error {
+	return m.simpleManager.WriteWithoutResponse(deviceName, serviceUUID, characteristicUUID, data)
+}
+
+// Request sends a command to a connected device and waits for its response
+func (m *Manager) Request(ctx context.Context, deviceName string, req Request) ([]byte, error) {
+	return m.simpleManager.Request(ctx, deviceName, req)
+}
@@ -0,0 +1,176 @@
+package ble_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"tinygo.org/x/bluetooth"
+)
+
+// testCommandUUID is a write-only characteristic next to the test sensor's notify one
+var testCommandUUID = bluetooth.New16BitUUID(0x2A39)
+
+// newTestCommandSensor adds a test sensor whose service also has the command characteristic
+func newTestCommandSensor(adapter *bletest.Adapter) (peripheral *bletest.Peripheral, notify, command *bletest.Characteristic) {
+	peripheral = bletest.NewPeripheral("Sensor", bletest.MustParseAddress("11:22:33:44:55:66"))
+	service := peripheral.AddService(testServiceUUID)
+	notify = service.AddCharacteristic(testCharacteristicUUID)
+	command = service.AddCharacteristic(testCommandUUID)
+	adapter.AddPeripheral(peripheral)
+	return peripheral, notify, command
+}
+
+// newTestCommander returns a connected manager for a test sensor that answers
+// every command written to it with notifications of answer(command), and the
+// channel its subscription handler delivers to
+func newTestCommander(t *testing.T, answer func(command []byte) [][]byte) (*ble.SimpleManager, *bletest.Peripheral, <-chan []byte) {
+	t.Helper()
+	adapter := bletest.NewAdapter()
+	peripheral, notify, command := newTestCommandSensor(adapter)
+	command.OnWrite(func(data []byte) {
+		for _, response := range answer(data) {
+			notify.Notify(response)
+		}
+	})
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	t.Cleanup(func() { m.Close() })
+	received := make(chan []byte, 4)
+	connectAll(t, m, testConfig("Sensor", received))
+	return m, peripheral, received
+}
+
+// testRequest returns a request written to the command characteristic and
+// answered on the notify one
+func testRequest(data ...byte) ble.Request {
+	return ble.Request{
+		ServiceUUID:                testServiceUUID,
+		CharacteristicUUID:         testCommandUUID,
+		Data:                       data,
+		ResponseCharacteristicUUID: testCharacteristicUUID,
+		Timeout:                    testTimeout,
+	}
+}
+
+func TestWrite(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	_, _, command := newTestCommandSensor(adapter)
+	written := make(chan []byte, 2)
+	command.OnWrite(func(data []byte) { written <- data })
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	connectAll(t, m, testConfig("Sensor", make(chan []byte, 1)))
+
+	// The command characteristic is not subscribed and discovered on first use
+	if err := m.Write("Sensor", testServiceUUID, testCommandUUID, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if data := receive(t, written); !bytes.Equal(data, []byte{1}) {
+		t.Errorf("Write delivered %v, want [1]", data)
+	}
+	if err := m.WriteWithoutResponse("Sensor", testServiceUUID, testCommandUUID, []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if data := receive(t, written); !bytes.Equal(data, []byte{2}) {
+		t.Errorf("WriteWithoutResponse delivered %v, want [2]", data)
+	}
+
+	if err := m.Write("Sensor", testServiceUUID, bluetooth.New16BitUUID(0x2A00), []byte{3}); err == nil {
+		t.Error("Write to a missing characteristic succeeded")
+	}
+	if err := m.Write("Other", testServiceUUID, testCommandUUID, []byte{4}); !errors.Is(err, ble.ErrNotConnected) {
+		t.Errorf("Write to an unknown device returned %v, want ErrNotConnected", err)
+	}
+}
+
+// emptyConnection is a connection whose discoveries find nothing without failing
+type emptyConnection struct{ ble.Connection }
+
+func (emptyConnection) DiscoverServices(uuids []bluetooth.UUID) ([]ble.Service, error) {
+	return nil, nil
+}
+
+type emptyService struct{ ble.Service }
+
+func (emptyService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]ble.Characteristic, error) {
+	return nil, nil
+}
+
+// serviceOnlyConnection finds the service, but none of its characteristics
+type serviceOnlyConnection struct{ ble.Connection }
+
+func (serviceOnlyConnection) DiscoverServices(uuids []bluetooth.UUID) ([]ble.Service, error) {
+	return []ble.Service{emptyService{}}, nil
+}
+
+func TestWriteWithEmptyDiscovery(t *testing.T) {
+	for _, conn := range []ble.Connection{emptyConnection{}, serviceOnlyConnection{}} {
+		device := &ble.SimpleDevice{Name: "Sensor", Connection: conn}
+		if err := device.Write(testServiceUUID, testCommandUUID, []byte{1}); err == nil {
+			t.Errorf("Write through %T succeeded without a characteristic", conn)
+		}
+	}
+}
+
+func TestRequestMatchesResponse(t *testing.T) {
+	m, _, received := newTestCommander(t, func(command []byte) [][]byte {
+		// An unrelated notification comes first
+		return [][]byte{{0x00}, append([]byte{0x80}, command...)}
+	})
+
+	for _, withoutResponse := range []bool{false, true} {
+		req := testRequest(0x07)
+		req.WithoutResponse = withoutResponse
+		req.Match = func(data []byte) bool { return data[0] == 0x80 }
+
+		response, err := m.Request(context.Background(), "Sensor", req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(response, []byte{0x80, 0x07}) {
+			t.Errorf("got response %v, want [128 7]", response)
+		}
+
+		// Only the unrelated notification reaches the handler
+		if data := receive(t, received); !bytes.Equal(data, []byte{0x00}) {
+			t.Errorf("handler got %v, want [0]", data)
+		}
+		select {
+		case data := <-received:
+			t.Errorf("handler also got %v, the response must not reach it", data)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	m, _, _ := newTestCommander(t, func(command []byte) [][]byte { return nil })
+
+	req := testRequest(0x01)
+	req.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	if _, err := m.Request(context.Background(), "Sensor", req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > testTimeout {
+		t.Errorf("Request returned after %s, want about %s", elapsed, req.Timeout)
+	}
+}
+
+func TestRequestDisconnectWhileWaiting(t *testing.T) {
+	var peripheral *bletest.Peripheral
+	m, peripheral, _ := newTestCommander(t, func(command []byte) [][]byte {
+		go peripheral.Drop()
+		return nil
+	})
+
+	if _, err := m.Request(context.Background(), "Sensor", testRequest(0x01)); !errors.Is(err, ble.ErrConnectionLost) {
+		t.Errorf("got %v, want ErrConnectionLost", err)
+	}
+}
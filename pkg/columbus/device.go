@@ -5,6 +5,7 @@ package columbus
 import (
 	"fmt"
 
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
 	"tinygo.org/x/bluetooth"
 )
 
@@ -20,6 +21,9 @@ var (
 	CharacteristicUUID = bluetooth.CharacteristicUUIDUARTTX
 )
 
+// Device is connected with ble.Manager.Register
+var _ ble.Peripheral = (*Device)(nil)
+
 // SignalHandler defines the function signature for handling pen signals
 type SignalHandler func(signal []byte) error
 
@@ -0,0 +1,120 @@
+package countries
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language of Country.Name
+const DefaultLanguage = "en"
+
+// embeddedNames holds the German, French and Spanish country names by alpha-2
+// code. They are compiled by hand, mostly after the ISO 3166-1 translations of
+// the iso-codes project and the short forms of CLDR, but some entries use other
+// common names (e.g. "Birmania" for MM in Spanish) and were not checked
+// against either source.
+//
+//go:embed country_names.json
+var embeddedNames []byte
+
+// translatedNames parses embeddedNames once
+var translatedNames = sync.OnceValues(func() (map[string]map[string]string, error) {
+	var names map[string]map[string]string
+	if err := json.Unmarshal(embeddedNames, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse country names: %v", err)
+	}
+	return names, nil
+})
+
+// NameIn returns the name of the country in lang, e.g. "de" or "fr-CH", or
+// the English Name if there is no translation
+func (c Country) NameIn(lang string) string {
+	if name, ok := c.Names[baseLanguage(lang)]; ok && name != "" {
+		return name
+	}
+	return c.Name
+}
+
+// baseLanguage reduces a language tag like "de-AT" or "fr_CH" to its lower-case language
+func baseLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// addTranslatedNames fills in the embedded translations of countries for
+// languages the dataset does not name itself
+func addTranslatedNames(countries []Country) error {
+	table, err := translatedNames()
+	if err != nil {
+		return err
+	}
+
+	for i := range countries {
+		translations, ok := table[countries[i].Alpha2Code]
+		if !ok {
+			continue
+		}
+		if countries[i].Names == nil {
+			countries[i].Names = make(map[string]string, len(translations))
+		}
+		for lang, name := range translations {
+			if _, ok := countries[i].Names[lang]; !ok {
+				countries[i].Names[lang] = name
+			}
+		}
+	}
+	return nil
+}
+
+// languages returns DefaultLanguage and the languages countries are translated to, sorted
+func languages(countries []Country) []string {
+	seen := map[string]bool{DefaultLanguage: true}
+	for _, country := range countries {
+		for lang := range country.Names {
+			seen[lang] = true
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for lang := range seen {
+		result = append(result, lang)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Option configures a Resolver
+type Option func(*Resolver)
+
+// WithLanguage sets the language of Resolver.DisplayName, e.g. "de"
+func WithLanguage(lang string) Option {
+	return func(r *Resolver) {
+		r.language = baseLanguage(lang)
+	}
+}
+
+// Language returns the display language of the resolver
+func (r *Resolver) Language() string {
+	return r.language
+}
+
+// DisplayName returns the name of country in the display language of the resolver
+func (r *Resolver) DisplayName(country *Country) string {
+	return country.NameIn(r.language)
+}
+
+// Languages returns the languages the loaded countries have names in, sorted
+func (r *Resolver) Languages() ([]string, error) {
+	d, err := r.dataset()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), d.languages...), nil
+}
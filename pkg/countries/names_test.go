@@ -0,0 +1,57 @@
+package countries
+
+import "testing"
+
+func TestNameIn(t *testing.T) {
+	r := NewResolver()
+	country, err := r.ResolveFromAlpha2Code("DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":      "Germany",
+		"en":    "Germany",
+		"de":    "Deutschland",
+		"de-AT": "Deutschland",
+		"fr_CH": "Allemagne",
+		"ES":    "Alemania",
+		"xx":    "Germany",
+	}
+	for lang, want := range tests {
+		if got := country.NameIn(lang); got != want {
+			t.Errorf("NameIn(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestResolveFromTranslatedName(t *testing.T) {
+	r := NewResolver(WithLanguage("fr"))
+	for _, name := range []string{"Spain", "spanien", "Espagne", " ESPAÑA "} {
+		country, err := r.ResolveFromName(name)
+		if err != nil {
+			t.Errorf("ResolveFromName(%q): %v", name, err)
+			continue
+		}
+		if got := r.DisplayName(country); got != "Espagne" {
+			t.Errorf("ResolveFromName(%q) displays as %q, want Espagne", name, got)
+		}
+	}
+}
+
+func TestReturnedNamesAreCopies(t *testing.T) {
+	r := NewResolver()
+	country, err := r.ResolveFromAlpha2Code("FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	country.Names["de"] = "changed"
+
+	again, err := r.ResolveFromAlpha2Code("FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := again.NameIn("de"); got != "Frankreich" {
+		t.Errorf("NameIn(de) = %q after modifying a returned country, want Frankreich", got)
+	}
+}
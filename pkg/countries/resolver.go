@@ -3,19 +3,28 @@
 package countries
 
 import (
+	"bytes"
+	_ "embed"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
-	"runtime"
+	"io"
+	"io/fs"
+	"maps"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// embeddedCountryData is the dataset of the standard Columbus globe
+//
+//go:embed country_codes.json
+var embeddedCountryData []byte
+
 // Country represents country information with codes and geographic data
 type Country struct {
 	Name                   string `json:"name"`
-	Alpha2Code             string `json:"alpha_2_code"`
-	Alpha3Code             string `json:"alpha_3_code"`
+	Alpha2Code             string `json:"alpha_2"`
+	Alpha3Code             string `json:"alpha_3"`
 	CountryCode            int    `json:"country_code"`
 	ISO3166_2              string `json:"iso_3166_2"`
 	Region                 string `json:"region"`
@@ -25,98 +34,159 @@ type Country struct {
 	SubRegionCode          int    `json:"sub_region_code"`
 	IntermediateRegionCode int    `json:"intermediate_region_code,omitempty"`
 	GlobeHex               string `json:"globe_hex"`
+
+	// Names holds the translated names by language, e.g. "de", see NameIn.
+	// Translations of the embedded table are added to every dataset on load.
+	Names map[string]string `json:"names,omitempty"`
+}
+
+// clone returns a copy of the country that shares no maps with it
+func (c Country) clone() Country {
+	c.Names = maps.Clone(c.Names)
+	return c
 }
 
-// Resolver handles country resolution from various input formats
+// Resolver handles country resolution from various input formats. It is safe
+// for concurrent use; the embedded data is loaded once on first use.
 type Resolver struct {
-	countries    []Country
-	hexToCountry map[string]*Country
-	loaded       bool
+	data     atomic.Pointer[dataset]
+	once     sync.Once
+	loadErr  error
+	language string // of DisplayName
 }
 
-// NewResolver creates a new country resolver instance
-func NewResolver() *Resolver {
-	return &Resolver{
-		hexToCountry: make(map[string]*Country),
-	}
+// dataset is a loaded country list with its lookup indexes. It is never
+// modified after creation, so lookups need no locking.
+type dataset struct {
+	countries []Country
+	byHex     map[string]int // upper-case globe hex -> index into countries
+	byCode    map[int]int
+	byAlpha2  map[string]int
+	byAlpha3  map[string]int
+	byName    map[string]int // normalised name in every language
+	terms     [][]searchTerm // by index, see Search
+	languages []string
 }
 
-// LoadCountryData loads country data from the JSON file
-func (r *Resolver) LoadCountryData() error {
-	if r.loaded {
-		return nil
+func newDataset(countries []Country) *dataset {
+	d := &dataset{
+		countries: countries,
+		byHex:     make(map[string]int, len(countries)),
+		byCode:    make(map[int]int, len(countries)),
+		byAlpha2:  make(map[string]int, len(countries)),
+		byAlpha3:  make(map[string]int, len(countries)),
+		byName:    make(map[string]int, len(countries)),
+		terms:     make([][]searchTerm, len(countries)),
+		languages: languages(countries),
 	}
-
-	// Get the path to the country data file
-	dataPath, err := r.getCountryDataPath()
-	if err != nil {
-		return fmt.Errorf("failed to locate country data: %v", err)
+	for i, country := range countries {
+		if country.GlobeHex != "" {
+			d.byHex[strings.ToUpper(country.GlobeHex)] = i
+		}
+		if country.CountryCode != 0 {
+			d.byCode[country.CountryCode] = i
+		}
+		d.byAlpha2[country.Alpha2Code] = i
+		d.byAlpha3[country.Alpha3Code] = i
+		d.byName[normalizeName(country.Name)] = i
+		for _, name := range country.Names {
+			d.byName[normalizeName(name)] = i
+		}
+		d.terms[i] = searchTerms(country)
 	}
+	return d
+}
 
-	// Read the JSON file
-	content, err := ioutil.ReadFile(dataPath)
-	if err != nil {
-		return fmt.Errorf("failed to read country data file: %v", err)
-	}
+// country returns a copy of the country at index i, so callers cannot modify the dataset
+func (d *dataset) country(i int) *Country {
+	country := d.countries[i].clone()
+	return &country
+}
 
-	// Parse JSON
-	if err := json.Unmarshal(content, &r.countries); err != nil {
-		return fmt.Errorf("failed to parse country data: %v", err)
-	}
+// normalizeName folds case and whitespace of a country name
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
 
-	// Build hex lookup map
-	r.buildHexLookupMap()
-	r.loaded = true
+// NewResolver creates a new country resolver instance
+func NewResolver(options ...Option) *Resolver {
+	r := &Resolver{language: DefaultLanguage}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
 
-	return nil
+// LoadCountryData loads the embedded country data, unless a dataset has
+// already been loaded. It is called by every lookup.
+func (r *Resolver) LoadCountryData() error {
+	r.once.Do(func() {
+		if r.data.Load() != nil {
+			return
+		}
+		d, err := parseCountryData(bytes.NewReader(embeddedCountryData))
+		if err != nil {
+			r.loadErr = err
+			return
+		}
+		// An override loaded in the meantime takes precedence
+		r.data.CompareAndSwap(nil, d)
+	})
+	return r.loadErr
 }
 
-// getCountryDataPath attempts to find the country_codes.json file
-func (r *Resolver) getCountryDataPath() (string, error) {
-	// Get the current file's directory
-	_, currentFile, _, ok := runtime.Caller(0)
-	if !ok {
-		return "", fmt.Errorf("could not determine package location")
+// LoadCountryDataFrom replaces the country data with the JSON array read from
+// reader, e.g. the mapping of a custom globe. The data is checked with
+// Validate; the current data is kept if it cannot be read or is invalid.
+func (r *Resolver) LoadCountryDataFrom(reader io.Reader) error {
+	d, err := parseCountryData(reader)
+	if err != nil {
+		return err
 	}
 
-	packageDir := filepath.Dir(currentFile)
+	r.data.Store(d)
+	return nil
+}
 
-	// Try various possible locations for the country data file
-	possiblePaths := []string{
-		filepath.Join(packageDir, "country_codes.json"),
-		filepath.Join(packageDir, "..", "..", "country_resolver", "country_codes.json"),
-		"./country_resolver/country_codes.json",
-		"./pkg/countries/country_codes.json",
-		"./country_codes.json",
+// parseCountryData reads and validates a JSON array of countries
+func parseCountryData(reader io.Reader) (*dataset, error) {
+	var countries []Country
+	if err := json.NewDecoder(reader).Decode(&countries); err != nil {
+		return nil, fmt.Errorf("failed to parse country data: %v", err)
 	}
-
-	for _, path := range possiblePaths {
-		if _, err := ioutil.ReadFile(path); err == nil {
-			return path, nil
-		}
+	if err := addTranslatedNames(countries); err != nil {
+		return nil, err
 	}
-
-	return "", fmt.Errorf("country_codes.json not found in any expected location")
+	if err := Validate(countries); err != nil {
+		return nil, err
+	}
+	return newDataset(countries), nil
 }
 
-// buildHexLookupMap creates a fast lookup map for hex codes
-func (r *Resolver) buildHexLookupMap() {
-	for i := range r.countries {
-		country := &r.countries[i]
-		if country.GlobeHex != "" {
-			// Store both uppercase and lowercase versions
-			r.hexToCountry[strings.ToUpper(country.GlobeHex)] = country
-			r.hexToCountry[strings.ToLower(country.GlobeHex)] = country
-		}
+// LoadCountryDataFS replaces the country data with the JSON file name in fsys
+func (r *Resolver) LoadCountryDataFS(fsys fs.FS, name string) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open country data file: %v", err)
 	}
+	defer file.Close()
+
+	return r.LoadCountryDataFrom(file)
 }
 
-// ResolveFromSignal resolves country from a Columbus pen signal
-func (r *Resolver) ResolveFromSignal(signal []byte) (*Country, error) {
+// dataset returns the current country data, loading the embedded data first if needed
+func (r *Resolver) dataset() (*dataset, error) {
+	if d := r.data.Load(); d != nil {
+		return d, nil
+	}
 	if err := r.LoadCountryData(); err != nil {
 		return nil, err
 	}
+	return r.data.Load(), nil
+}
 
+// ResolveFromSignal resolves country from a Columbus pen signal
+func (r *Resolver) ResolveFromSignal(signal []byte) (*Country, error) {
 	// Convert signal to hex string
 	hexStr := fmt.Sprintf("%x", signal)
 
@@ -132,7 +202,8 @@ func (r *Resolver) ResolveFromSignal(signal []byte) (*Country, error) {
 
 // ResolveFromHex resolves country from a hex code string
 func (r *Resolver) ResolveFromHex(hex string) (*Country, error) {
-	if err := r.LoadCountryData(); err != nil {
+	d, err := r.dataset()
+	if err != nil {
 		return nil, err
 	}
 
@@ -142,71 +213,92 @@ func (r *Resolver) ResolveFromHex(hex string) (*Country, error) {
 		return nil, fmt.Errorf("empty hex code")
 	}
 
-	// Try to find the country
-	if country, exists := r.hexToCountry[hex]; exists {
-		return country, nil
+	if i, ok := d.byHex[strings.ToUpper(hex)]; ok {
+		return d.country(i), nil
 	}
-
-	// Try uppercase version
-	if country, exists := r.hexToCountry[strings.ToUpper(hex)]; exists {
-		return country, nil
-	}
-
 	return nil, fmt.Errorf("country not found for hex code: %s", hex)
 }
 
 // ResolveFromCountryCode resolves country from a numeric country code
 func (r *Resolver) ResolveFromCountryCode(code int) (*Country, error) {
-	if err := r.LoadCountryData(); err != nil {
+	d, err := r.dataset()
+	if err != nil {
 		return nil, err
 	}
 
-	for i := range r.countries {
-		if r.countries[i].CountryCode == code {
-			return &r.countries[i], nil
-		}
+	if i, ok := d.byCode[code]; ok {
+		return d.country(i), nil
 	}
-
 	return nil, fmt.Errorf("country not found for code: %d", code)
 }
 
 // ResolveFromAlpha2Code resolves country from a 2-letter country code (e.g., "US")
 func (r *Resolver) ResolveFromAlpha2Code(code string) (*Country, error) {
-	if err := r.LoadCountryData(); err != nil {
+	d, err := r.dataset()
+	if err != nil {
 		return nil, err
 	}
 
 	code = strings.ToUpper(strings.TrimSpace(code))
-	for i := range r.countries {
-		if r.countries[i].Alpha2Code == code {
-			return &r.countries[i], nil
-		}
+	if i, ok := d.byAlpha2[code]; ok {
+		return d.country(i), nil
 	}
-
 	return nil, fmt.Errorf("country not found for alpha-2 code: %s", code)
 }
 
+// ResolveFromAlpha3Code resolves country from a 3-letter country code (e.g., "USA")
+func (r *Resolver) ResolveFromAlpha3Code(code string) (*Country, error) {
+	d, err := r.dataset()
+	if err != nil {
+		return nil, err
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if i, ok := d.byAlpha3[code]; ok {
+		return d.country(i), nil
+	}
+	return nil, fmt.Errorf("country not found for alpha-3 code: %s", code)
+}
+
+// ResolveFromName resolves country from its name in any of its languages,
+// ignoring case and extra whitespace
+func (r *Resolver) ResolveFromName(name string) (*Country, error) {
+	d, err := r.dataset()
+	if err != nil {
+		return nil, err
+	}
+
+	if i, ok := d.byName[normalizeName(name)]; ok {
+		return d.country(i), nil
+	}
+	return nil, fmt.Errorf("country not found for name: %s", name)
+}
+
 // GetAllCountries returns all loaded countries
 func (r *Resolver) GetAllCountries() ([]Country, error) {
-	if err := r.LoadCountryData(); err != nil {
+	d, err := r.dataset()
+	if err != nil {
 		return nil, err
 	}
 
-	result := make([]Country, len(r.countries))
-	copy(result, r.countries)
+	result := make([]Country, len(d.countries))
+	for i, country := range d.countries {
+		result[i] = country.clone()
+	}
 	return result, nil
 }
 
 // GetCountriesByRegion returns all countries in a specific region
 func (r *Resolver) GetCountriesByRegion(region string) ([]Country, error) {
-	if err := r.LoadCountryData(); err != nil {
+	d, err := r.dataset()
+	if err != nil {
 		return nil, err
 	}
 
 	var result []Country
-	for _, country := range r.countries {
+	for _, country := range d.countries {
 		if strings.EqualFold(country.Region, region) {
-			result = append(result, country)
+			result = append(result, country.clone())
 		}
 	}
 
@@ -245,6 +337,16 @@ func LoadCountryData() error {
 	return defaultResolver.LoadCountryData()
 }
 
+// LoadCountryDataFrom replaces the country data of the default resolver
+func LoadCountryDataFrom(reader io.Reader) error {
+	return defaultResolver.LoadCountryDataFrom(reader)
+}
+
+// LoadCountryDataFS replaces the country data of the default resolver with the JSON file name in fsys
+func LoadCountryDataFS(fsys fs.FS, name string) error {
+	return defaultResolver.LoadCountryDataFS(fsys, name)
+}
+
 // Legacy function for backward compatibility
 func Resolve_By_Bluetooth_Signal(bluetooth_signal string) (*Country, error) {
 	// This function expects a hex string, not bytes
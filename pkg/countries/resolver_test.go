@@ -0,0 +1,54 @@
+package countries
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentFirstUse(t *testing.T) {
+	r := NewResolver()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			country, err := r.ResolveFromHex("3A99")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if country.Name != "Afghanistan" {
+				t.Errorf("got %s, want Afghanistan", country.Name)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEmbeddedDataDoesNotReplaceOverride(t *testing.T) {
+	data := []byte(`[{"name": "Atlantis", "alpha_2": "XA", "alpha_3": "XAT", "globe_hex": "3A99"}]`)
+	r := NewResolver()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ResolveFromHex("3A99")
+		}()
+	}
+	if err := r.LoadCountryDataFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	country, err := r.ResolveFromHex("3A99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if country.Name != "Atlantis" {
+		t.Errorf("got %s, want the override Atlantis", country.Name)
+	}
+}
@@ -0,0 +1,259 @@
+package countries
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Match is a country found by Search
+type Match struct {
+	Country Country
+	Score   float64 // 1 for an exact match, lower for prefix, substring and typo matches
+	Field   string  // what matched, e.g. "name", "names.de", "alternate_name", "alpha_3" or "globe_hex"
+	Matched string  // the matched value as stored in the dataset
+}
+
+// Scores of the match kinds, see matchScore
+const (
+	scoreExact      = 1.0
+	scorePrefix     = 0.9
+	scoreWordPrefix = 0.8
+	scoreSubstring  = 0.7
+	scoreTypo       = 0.6 // minus typoPenalty per edit
+	typoPenalty     = 0.1
+)
+
+// alternateNames holds common English names and abbreviations of countries
+// whose name in the dataset is a different, usually official one, by alpha-2 code
+var alternateNames = map[string][]string{
+	"BN": {"Brunei"},
+	"CD": {"DR Congo", "DRC", "Congo-Kinshasa"},
+	"CG": {"Congo-Brazzaville"},
+	"CI": {"Ivory Coast"},
+	"CV": {"Cape Verde"},
+	"CZ": {"Czech Republic"},
+	"FK": {"Falklands"},
+	"GB": {"UK", "Great Britain", "Britain"},
+	"LA": {"Laos"},
+	"MK": {"Macedonia"},
+	"MM": {"Burma"},
+	"NL": {"Holland"},
+	"SY": {"Syria"},
+	"SZ": {"Swaziland"},
+	"TL": {"East Timor"},
+	"TR": {"Türkiye"},
+	"US": {"USA", "America"},
+	"VA": {"Vatican", "Vatican City"},
+	"VN": {"Vietnam"},
+}
+
+// searchTerm is one value of a country that Search matches against
+type searchTerm struct {
+	field  string
+	value  string
+	folded string // see foldSearch
+	code   bool   // codes only match exactly
+}
+
+// searchTerms returns the names and codes of country to match against
+func searchTerms(country Country) []searchTerm {
+	terms := []searchTerm{{field: "name", value: country.Name}}
+
+	langs := make([]string, 0, len(country.Names))
+	for lang := range country.Names {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		terms = append(terms, searchTerm{field: "names." + lang, value: country.Names[lang]})
+	}
+
+	codes := []searchTerm{
+		{field: "alpha_2", value: country.Alpha2Code},
+		{field: "alpha_3", value: country.Alpha3Code},
+		{field: "globe_hex", value: country.GlobeHex},
+	}
+	if country.CountryCode != 0 {
+		codes = append(codes, searchTerm{field: "country_code", value: strconv.Itoa(country.CountryCode)})
+	}
+	for _, code := range codes {
+		if code.value != "" {
+			code.code = true
+			terms = append(terms, code)
+		}
+	}
+
+	// After the codes, so "usa" is still reported as an alpha-3 match
+	for _, name := range alternateNames[country.Alpha2Code] {
+		terms = append(terms, searchTerm{field: "alternate_name", value: name})
+	}
+
+	for i := range terms {
+		terms[i].folded = foldSearch(terms[i].value)
+	}
+	return terms
+}
+
+// Search returns the countries whose name in any language, common alternate
+// name such as "UK" or "Holland", or alpha-2, alpha-3, globe hex or numeric
+// code matches query, best matches first.
+// Names match ignoring case, diacritics and punctuation, so "cote divoire"
+// finds Côte d'Ivoire, by prefix or substring, and with a few typos.
+func (r *Resolver) Search(query string) ([]Match, error) {
+	d, err := r.dataset()
+	if err != nil {
+		return nil, err
+	}
+
+	folded := foldSearch(query)
+	if folded == "" {
+		return nil, nil
+	}
+
+	var matches []Match
+	for i, terms := range d.terms {
+		best := Match{}
+		for _, term := range terms {
+			if score := matchScore(folded, term); score > best.Score {
+				best = Match{Score: score, Field: term.field, Matched: term.value}
+			}
+		}
+		if best.Score > 0 {
+			best.Country = d.countries[i].clone()
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		// Of two prefix matches, the shorter name is the closer one
+		if li, lj := len(matches[i].Matched), len(matches[j].Matched); li != lj {
+			return li < lj
+		}
+		return matches[i].Country.Name < matches[j].Country.Name
+	})
+	return matches, nil
+}
+
+// matchScore rates how well the folded query matches term, 0 if not at all
+func matchScore(query string, term searchTerm) float64 {
+	switch {
+	case term.folded == query:
+		return scoreExact
+	case term.code:
+		return 0
+	case strings.HasPrefix(term.folded, query):
+		return scorePrefix
+	case strings.Contains(" "+term.folded, " "+query):
+		return scoreWordPrefix
+	case strings.Contains(term.folded, query):
+		return scoreSubstring
+	}
+
+	q := []rune(query)
+	allowed := maxTypos(len(q))
+	if allowed == 0 {
+		return 0
+	}
+
+	// Compare against the whole name and each of its word-aligned tails, both
+	// in full and cut to the query length so partial input matches too
+	name := []rune(term.folded)
+	distance := allowed + 1
+	for start := 0; start < len(name); start++ {
+		if start > 0 && name[start-1] != ' ' {
+			continue
+		}
+		tail := name[start:]
+		distance = min(distance, editDistance(q, tail))
+		if len(tail) > len(q) {
+			distance = min(distance, editDistance(q, tail[:len(q)]))
+		}
+	}
+	if distance > allowed {
+		return 0
+	}
+	return scoreTypo - typoPenalty*float64(distance)
+}
+
+// maxTypos returns the number of edits tolerated in a query of n letters
+func maxTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance of a and b:
+// insertions, deletions, substitutions and transpositions of adjacent letters
+func editDistance(a, b []rune) int {
+	// rows[i][j] is the distance of a[:i] and b[:j]; only three rows are kept
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// diacritics maps lower-case letters with diacritics to their base letters
+var diacritics = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// foldSearch normalises text for Search: lower case without diacritics,
+// apostrophes dropped and other punctuation turned into single spaces
+func foldSearch(text string) string {
+	text = diacritics.Replace(strings.ToLower(text))
+
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		switch {
+		case r == '\'' || r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Search finds countries using the default resolver, see Resolver.Search
+func Search(query string) ([]Match, error) {
+	return defaultResolver.Search(query)
+}
@@ -0,0 +1,87 @@
+package countries
+
+import "testing"
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // alpha-2 code of the best match
+		field string
+	}{
+		{"cote divoire", "CI", "name"},
+		{"Deutschland", "DE", "names.de"},
+		{"deutchland", "DE", "names.de"},
+		{"swizterland", "CH", "name"},
+		{"ESPANA", "ES", "names.es"},
+		{"de", "DE", "alpha_2"},
+		{"usa", "US", "alpha_3"},
+		{"3a99", "AF", "globe_hex"},
+		{"276", "DE", "country_code"},
+		{"united states", "US", "name"},
+		{"kongo", "CG", "names.de"},
+		{"ivory coast", "CI", "alternate_name"},
+		{"czech republic", "CZ", "alternate_name"},
+		{"holland", "NL", "alternate_name"},
+		{"burma", "MM", "alternate_name"},
+		{"uk", "GB", "alternate_name"},
+	}
+
+	r := NewResolver()
+	for _, tt := range tests {
+		matches, err := r.Search(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) == 0 {
+			t.Errorf("Search(%q) found nothing, want %s", tt.query, tt.want)
+			continue
+		}
+		if best := matches[0]; best.Country.Alpha2Code != tt.want || best.Field != tt.field {
+			t.Errorf("Search(%q) = %s by %s, want %s by %s", tt.query, best.Country.Alpha2Code, best.Field, tt.want, tt.field)
+		}
+	}
+}
+
+func TestSearchRanksExactBeforeFuzzy(t *testing.T) {
+	matches, err := NewResolver().Search("niger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) < 2 {
+		t.Fatalf("got %d matches, want Niger and Nigeria", len(matches))
+	}
+	if matches[0].Country.Alpha2Code != "NE" || matches[0].Score != scoreExact {
+		t.Errorf("best match %s with score %v, want NE with %v", matches[0].Country.Alpha2Code, matches[0].Score, scoreExact)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Errorf("match %d (%s, %v) ranks above a better one", i, matches[i].Country.Name, matches[i].Score)
+		}
+	}
+}
+
+func TestSearchIgnoresEmptyQuery(t *testing.T) {
+	matches, err := NewResolver().Search(" '-' ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("got %d matches for an empty query", len(matches))
+	}
+}
+
+func TestSearchAlternateNameRanksFirst(t *testing.T) {
+	matches, err := NewResolver().Search("UK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) < 2 {
+		t.Fatalf("got %d matches, want the United Kingdom and Ukraine", len(matches))
+	}
+	if matches[0].Country.Alpha2Code != "GB" || matches[0].Score != scoreExact {
+		t.Errorf("best match %s with score %v, want GB with %v", matches[0].Country.Alpha2Code, matches[0].Score, scoreExact)
+	}
+	if matches[1].Country.Alpha2Code != "UA" {
+		t.Errorf("second match %s, want Ukraine by prefix", matches[1].Country.Alpha2Code)
+	}
+}
@@ -0,0 +1,212 @@
+package countries
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DataError is one problem of an entry in a country dataset
+type DataError struct {
+	Index   int    // position of the entry in the dataset
+	Country string // name of the entry
+	Field   string // JSON field, e.g. "globe_hex"
+	Problem string
+}
+
+func (e DataError) Error() string {
+	return fmt.Sprintf("country %d (%s): %s: %s", e.Index, e.Country, e.Field, e.Problem)
+}
+
+// ValidationError lists all problems found in a country dataset
+type ValidationError struct {
+	Errors []DataError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid country data: %s", strings.Join(messages, "; "))
+}
+
+// Unwrap returns the individual problems
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Validate checks a country dataset and returns a *ValidationError listing
+// duplicate or malformed globe_hex values, missing, malformed or duplicate ISO
+// codes, names used by several entries in any language and region names
+// whose codes disagree between entries. Countries without a globe_hex are not
+// on the globe and allowed, as are countries without a numeric country_code.
+func Validate(countries []Country) error {
+	v := &validator{
+		owners:     make(map[string]map[string]int),
+		nameOwners: make(map[string]int),
+		regions:    make(map[string]*regionCodes),
+	}
+	for i := range countries {
+		v.check(i, &countries[i], countries)
+	}
+
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+// regionCodes maps the names of one region level to codes and back
+type regionCodes struct {
+	byName map[string]int
+	byCode map[int]string
+}
+
+type validator struct {
+	errors     []DataError
+	owners     map[string]map[string]int // by field: value -> index of the first entry using it
+	nameOwners map[string]int            // normalised name in any language -> index
+	regions    map[string]*regionCodes   // by code field
+}
+
+func (v *validator) report(index int, country *Country, field, format string, args ...any) {
+	v.errors = append(v.errors, DataError{
+		Index:   index,
+		Country: country.Name,
+		Field:   field,
+		Problem: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) check(index int, country *Country, countries []Country) {
+	if country.GlobeHex != "" {
+		hex := strings.ToUpper(country.GlobeHex)
+		if !isGlobeHex(hex) {
+			v.report(index, country, "globe_hex", "malformed hex code %q, expected 4 hex digits", country.GlobeHex)
+		} else {
+			v.checkUnique(index, country, countries, "globe_hex", hex)
+		}
+	}
+
+	if !isISOCode(country.Alpha2Code, 2) {
+		v.report(index, country, "alpha_2", "missing or malformed ISO 3166-1 alpha-2 code %q", country.Alpha2Code)
+	} else {
+		v.checkUnique(index, country, countries, "alpha_2", country.Alpha2Code)
+	}
+	if !isISOCode(country.Alpha3Code, 3) {
+		v.report(index, country, "alpha_3", "missing or malformed ISO 3166-1 alpha-3 code %q", country.Alpha3Code)
+	} else {
+		v.checkUnique(index, country, countries, "alpha_3", country.Alpha3Code)
+	}
+	if country.CountryCode != 0 {
+		v.checkUnique(index, country, countries, "country_code", strconv.Itoa(country.CountryCode))
+	}
+	v.checkNames(index, country, countries)
+
+	v.checkRegion(index, country, "region_code", country.Region, country.RegionCode)
+	v.checkRegion(index, country, "sub_region_code", country.SubRegion, country.SubRegionCode)
+	v.checkRegion(index, country, "intermediate_region_code", country.IntermediateRegion, country.IntermediateRegionCode)
+}
+
+// checkUnique checks that no earlier entry has the same value in field
+func (v *validator) checkUnique(index int, country *Country, countries []Country, field, value string) {
+	owners, ok := v.owners[field]
+	if !ok {
+		owners = make(map[string]int)
+		v.owners[field] = owners
+	}
+
+	if owner, ok := owners[value]; ok {
+		v.report(index, country, field, "%s is already used by %s", value, countries[owner].Name)
+		return
+	}
+	owners[value] = index
+}
+
+// checkNames checks that no other entry has the name or one of the
+// translations of country in any language
+func (v *validator) checkNames(index int, country *Country, countries []Country) {
+	langs := make([]string, 0, len(country.Names))
+	for lang := range country.Names {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	check := func(field, name string) {
+		key := normalizeName(name)
+		if owner, ok := v.nameOwners[key]; ok && owner != index {
+			v.report(index, country, field, "%q is already used by %s", name, countries[owner].Name)
+			return
+		}
+		v.nameOwners[key] = index
+	}
+
+	check("name", country.Name)
+	for _, lang := range langs {
+		check("names."+lang, country.Names[lang])
+	}
+}
+
+// checkRegion checks that a region name and its code are set together and
+// match those of earlier entries
+func (v *validator) checkRegion(index int, country *Country, field, name string, code int) {
+	switch {
+	case name == "" && code == 0:
+		return
+	case name == "":
+		v.report(index, country, field, "code %d without a region name", code)
+		return
+	case code == 0:
+		v.report(index, country, field, "missing code for region %q", name)
+		return
+	}
+
+	codes, ok := v.regions[field]
+	if !ok {
+		codes = &regionCodes{byName: make(map[string]int), byCode: make(map[int]string)}
+		v.regions[field] = codes
+	}
+
+	if known, ok := codes.byName[name]; ok && known != code {
+		v.report(index, country, field, "region %q has code %d, but %d elsewhere", name, code, known)
+		return
+	}
+	if known, ok := codes.byCode[code]; ok && known != name {
+		v.report(index, country, field, "code %d belongs to region %q, but %q elsewhere", code, name, known)
+		return
+	}
+	codes.byName[name] = code
+	codes.byCode[code] = name
+}
+
+// isGlobeHex reports whether hex consists of exactly 4 upper-case hex digits
+func isGlobeHex(hex string) bool {
+	if len(hex) != 4 {
+		return false
+	}
+	for _, c := range hex {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isISOCode reports whether code consists of exactly n upper-case letters
+func isISOCode(code string, n int) bool {
+	if len(code) != n {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package countries
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestShippedDataIsValid(t *testing.T) {
+	var countries []Country
+	if err := json.Unmarshal(embeddedCountryData, &countries); err != nil {
+		t.Fatalf("failed to parse country_codes.json: %v", err)
+	}
+	if err := addTranslatedNames(countries); err != nil {
+		t.Fatal(err)
+	}
+
+	var invalid *ValidationError
+	if err := Validate(countries); errors.As(err, &invalid) {
+		for _, problem := range invalid.Errors {
+			t.Error(problem)
+		}
+	} else if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShippedDataResolvesISOCodes(t *testing.T) {
+	r := NewResolver()
+	if err := r.LoadCountryData(); err != nil {
+		t.Fatal(err)
+	}
+
+	country, err := r.ResolveFromAlpha2Code("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if country.Name != "Germany" || country.Alpha3Code != "DEU" {
+		t.Errorf("got %s (%s, %s), want Germany (DE, DEU)", country.Name, country.Alpha2Code, country.Alpha3Code)
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	valid := Country{
+		Name:          "Germany",
+		Alpha2Code:    "DE",
+		Alpha3Code:    "DEU",
+		Region:        "Europe",
+		RegionCode:    150,
+		SubRegion:     "Western Europe",
+		SubRegionCode: 155,
+		GlobeHex:      "3AC4",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Country)
+		field  string
+	}{
+		{"duplicate globe hex", func(c *Country) { c.GlobeHex = "3ac4" }, "globe_hex"},
+		{"malformed globe hex", func(c *Country) { c.GlobeHex = "3B4E__" }, "globe_hex"},
+		{"missing alpha-2", func(c *Country) { c.Alpha2Code = "" }, "alpha_2"},
+		{"malformed alpha-3", func(c *Country) { c.Alpha3Code = "de" }, "alpha_3"},
+		{"region code mismatch", func(c *Country) { c.RegionCode = 142 }, "region_code"},
+		{"missing sub-region code", func(c *Country) { c.SubRegionCode = 0 }, "sub_region_code"},
+		{"shared sub-region code", func(c *Country) { c.SubRegion = "Northern Europe" }, "sub_region_code"},
+		{"duplicate alpha-3", func(c *Country) { c.Alpha3Code = "DEU" }, "alpha_3"},
+		{"duplicate name", func(c *Country) { c.Name = " germany" }, "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := valid
+			other.Name, other.Alpha2Code, other.Alpha3Code, other.CountryCode, other.GlobeHex = "Austria", "AT", "AUT", 40, "3B31"
+			tt.modify(&other)
+
+			err := Validate([]Country{valid, other})
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %v, want a *ValidationError", err)
+			}
+			if len(invalid.Errors) != 1 {
+				t.Fatalf("got %d problems, want 1: %v", len(invalid.Errors), err)
+			}
+			if problem := invalid.Errors[0]; problem.Index != 1 || problem.Field != tt.field {
+				t.Errorf("got problem %v, want one of country 1 in %s", problem, tt.field)
+			}
+		})
+	}
+}
+
+func TestInvalidDataKeepsCurrentData(t *testing.T) {
+	r := NewResolver()
+	if err := r.LoadCountryData(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`[{"name": "Atlantis", "alpha_2": "AT", "alpha_3": "ATL", "globe_hex": "XXXX"}]`)
+	if err := r.LoadCountryDataFrom(bytes.NewReader(data)); err == nil {
+		t.Fatal("invalid data was accepted")
+	}
+	if _, err := r.ResolveFromHex("3A99"); err != nil {
+		t.Errorf("shipped data was replaced: %v", err)
+	}
+}
+
+func TestCountriesWithoutNumericCode(t *testing.T) {
+	data := []byte(`[
+		{"name": "Atlantis", "alpha_2": "XA", "alpha_3": "XAT", "globe_hex": "3A99"},
+		{"name": "Lemuria", "alpha_2": "XL", "alpha_3": "XLM", "globe_hex": "3A9A"}
+	]`)
+	r := NewResolver()
+	if err := r.LoadCountryDataFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if country, err := r.ResolveFromCountryCode(0); err == nil {
+		t.Errorf("code 0 resolved to %s", country.Name)
+	}
+	if matches, _ := r.Search("0"); len(matches) != 0 {
+		t.Errorf("search for 0 found %d countries", len(matches))
+	}
+}
@@ -0,0 +1,209 @@
+package simulation
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/columbus"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/timeular"
+	"tinygo.org/x/bluetooth"
+)
+
+// subscriptionPollInterval is how often "await subscription" checks the characteristic
+const subscriptionPollInterval = 5 * time.Millisecond
+
+// device holds the behaviour shared by all virtual devices
+type device struct {
+	peripheral     *bletest.Peripheral
+	characteristic *bletest.Characteristic
+}
+
+// Peripheral returns the underlying virtual peripheral, to be added to a bletest.Adapter
+func (d *device) Peripheral() *bletest.Peripheral {
+	return d.peripheral
+}
+
+// Drop simulates a link loss
+func (d *device) Drop() {
+	d.peripheral.Drop()
+}
+
+// Send emits a raw notification on the device's data characteristic
+func (d *device) Send(data []byte) error {
+	return d.characteristic.Notify(data)
+}
+
+// AwaitSubscription blocks until the manager has enabled notifications or ctx is cancelled
+func (d *device) AwaitSubscription(ctx context.Context) error {
+	ticker := time.NewTicker(subscriptionPollInterval)
+	defer ticker.Stop()
+
+	for !d.characteristic.IsSubscribed() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// execute runs the commands every device understands
+func (d *device) execute(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "await":
+		if len(args) != 1 || args[0] != "subscription" {
+			return fmt.Errorf("usage: await subscription")
+		}
+		return d.AwaitSubscription(ctx)
+
+	case "drop":
+		if len(args) > 1 || (len(args) == 1 && args[0] != "connection") {
+			return fmt.Errorf("usage: drop [connection]")
+		}
+		d.Drop()
+		return nil
+
+	case "advertising":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			return fmt.Errorf("usage: advertising on|off")
+		}
+		d.peripheral.SetAdvertising(args[0] == "on")
+		return nil
+
+	case "send":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: send <hex payload>")
+		}
+		data, err := hex.DecodeString(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid payload %q: %v", args[0], err)
+		}
+		return d.Send(data)
+	}
+
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// ColumbusPen is a virtual "COLUMBUS Video Pen" exposing the Nordic UART TX characteristic
+type ColumbusPen struct {
+	device
+}
+
+// NewColumbusPen creates a virtual Columbus pen with the given address
+func NewColumbusPen(address bluetooth.Address) *ColumbusPen {
+	peripheral := bletest.NewPeripheral(columbus.DeviceName, address)
+	peripheral.AdvertiseServiceUUID(columbus.ServiceUUID)
+	characteristic := peripheral.AddService(columbus.ServiceUUID).AddCharacteristic(columbus.CharacteristicUUID)
+
+	return &ColumbusPen{device{peripheral: peripheral, characteristic: characteristic}}
+}
+
+// Tap emits the signal the pen sends when it touches the country with the given globe hex code
+func (p *ColumbusPen) Tap(globeHex string) error {
+	signal, err := ColumbusSignal(globeHex)
+	if err != nil {
+		return err
+	}
+	return p.Send(signal)
+}
+
+// Execute implements Target. In addition to the common commands it understands
+// "tap [globe_hex] <hex>".
+func (p *ColumbusPen) Execute(ctx context.Context, command string, args []string) error {
+	if command != "tap" {
+		return p.execute(ctx, command, args)
+	}
+
+	if len(args) == 2 && args[0] == "globe_hex" {
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: tap [globe_hex] <hex>")
+	}
+	return p.Tap(args[0])
+}
+
+// ColumbusSignal builds a pen signal carrying globeHex at bytes 5-6, where
+// columbus.SignalToCountryHex expects it.
+func ColumbusSignal(globeHex string) ([]byte, error) {
+	code, err := hex.DecodeString(globeHex)
+	if err != nil || len(code) != 2 {
+		return nil, fmt.Errorf("invalid globe hex %q: expected 4 hex digits", globeHex)
+	}
+
+	signal := make([]byte, 8)
+	copy(signal[5:7], code)
+	return signal, nil
+}
+
+// TimeularTracker is a virtual "Timeular Tracker" exposing the side characteristic
+type TimeularTracker struct {
+	device
+	side byte
+	mu   sync.Mutex
+}
+
+// NewTimeularTracker creates a virtual Timeular tracker with the given advertised name and address
+func NewTimeularTracker(name string, address bluetooth.Address) *TimeularTracker {
+	if name == "" {
+		name = timeular.DefaultDeviceName
+	}
+
+	peripheral := bletest.NewPeripheral(name, address)
+	peripheral.AdvertiseServiceUUID(timeular.ServiceUUID)
+	characteristic := peripheral.AddService(timeular.ServiceUUID).AddCharacteristic(timeular.CharacteristicUUID)
+
+	return &TimeularTracker{device: device{peripheral: peripheral, characteristic: characteristic}}
+}
+
+// Flip turns the tracker to the given side. The side becomes the value of the
+// characteristic and is notified if the central has enabled notifications.
+func (t *TimeularTracker) Flip(side byte) error {
+	if !timeular.IsValidSide(side) {
+		return fmt.Errorf("invalid side value: %d (must be 1-%d)", side, timeular.GetSupportedSides())
+	}
+
+	t.mu.Lock()
+	t.side = side
+	t.mu.Unlock()
+
+	t.characteristic.SetValue([]byte{side})
+	if !t.characteristic.IsSubscribed() {
+		return nil
+	}
+	return t.Send([]byte{side})
+}
+
+// Side returns the side the tracker was last flipped to, or 0 if it was never flipped
+func (t *TimeularTracker) Side() byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.side
+}
+
+// Execute implements Target. In addition to the common commands it understands
+// "flip [to side] <n>".
+func (t *TimeularTracker) Execute(ctx context.Context, command string, args []string) error {
+	if command != "flip" {
+		return t.execute(ctx, command, args)
+	}
+
+	if len(args) == 3 && args[0] == "to" && args[1] == "side" {
+		args = args[2:]
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: flip [to side] <n>")
+	}
+
+	side, err := strconv.ParseUint(args[0], 10, 8)
+	if err != nil {
+		return fmt.Errorf("invalid side %q", args[0])
+	}
+	return t.Flip(byte(side))
+}
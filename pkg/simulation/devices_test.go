@@ -0,0 +1,165 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/columbus"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/countries"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/timeular"
+)
+
+// testTimeout bounds every script and wait
+const testTimeout = 5 * time.Second
+
+// play runs src against target in the background and returns its outcome
+func play(ctx context.Context, src string, target Target) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- MustParseScript(src).Run(ctx, target) }()
+	return done
+}
+
+// connect connects configs through m and fails the test if any of them fails
+func connect(t *testing.T, m *ble.SimpleManager, configs ...ble.DeviceConfig) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	for _, result := range m.ConnectDevicesContext(ctx, configs) {
+		if result.Err != nil {
+			t.Fatalf("failed to connect %s: %v", result.Name, result.Err)
+		}
+	}
+}
+
+// await returns the next value of ch
+func await[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(testTimeout):
+		t.Fatalf("no %s within %s", what, testTimeout)
+		var zero T
+		return zero
+	}
+}
+
+// newPen returns a virtual pen on adapter and the config of a Columbus device
+// that reports the name of every tapped country to tapped
+func newPen(adapter *bletest.Adapter, tapped chan<- string) (*ColumbusPen, ble.DeviceConfig) {
+	pen := NewColumbusPen(bletest.MustParseAddress("C0:1B:05:00:00:01"))
+	adapter.AddPeripheral(pen.Peripheral())
+
+	device := columbus.NewDevice()
+	device.OnSignal(func(signal []byte) error {
+		countryHex, err := columbus.SignalToCountryHex(signal)
+		if err != nil {
+			return err
+		}
+		country, err := countries.ResolveFromHex(countryHex)
+		if err != nil {
+			return err
+		}
+		tapped <- country.Name
+		return nil
+	})
+
+	return pen, ble.DeviceConfig{
+		Name:                device.GetName(),
+		ServiceUUID:         device.GetServiceUUID(),
+		CharacteristicUUID:  device.GetCharacteristicUUID(),
+		NotificationHandler: device.ProcessNotification,
+		ReconnectPolicy:     ble.ReconnectPolicy{InitialDelay: 10 * time.Millisecond},
+	}
+}
+
+func TestPenTapResolvesCountry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	adapter := bletest.NewAdapter()
+	tapped := make(chan string, 1)
+	pen, config := newPen(adapter, tapped)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	done := play(ctx, "await subscription\ntap globe_hex 3A99", pen)
+	connect(t, m, config)
+
+	if err := await(t, done, "end of script"); err != nil {
+		t.Fatal(err)
+	}
+	if country := await(t, tapped, "country"); country != "Afghanistan" {
+		t.Errorf("got %s, want Afghanistan", country)
+	}
+}
+
+func TestTrackerFlipReachesSideHandler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	adapter := bletest.NewAdapter()
+	tracker := NewTimeularTracker("", bletest.MustParseAddress("7E:0A:12:00:00:01"))
+	adapter.AddPeripheral(tracker.Peripheral())
+
+	device := timeular.NewDevice()
+	sides := make(chan byte, 1)
+	device.OnSideChange(func(deviceName string, side byte) error {
+		sides <- side
+		return nil
+	})
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	done := play(ctx, "await subscription\nflip to side 5", tracker)
+	connect(t, m, ble.DeviceConfig{
+		Name:                device.GetName(),
+		ServiceUUID:         device.GetServiceUUID(),
+		CharacteristicUUID:  device.GetCharacteristicUUID(),
+		NotificationHandler: device.ProcessNotification,
+	})
+
+	if err := await(t, done, "end of script"); err != nil {
+		t.Fatal(err)
+	}
+	if side := await(t, sides, "side change"); side != 5 {
+		t.Errorf("got side %d, want 5", side)
+	}
+	if side := tracker.Side(); side != 5 {
+		t.Errorf("tracker is on side %d, want 5", side)
+	}
+}
+
+func TestDropConnectionReconnects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	adapter := bletest.NewAdapter()
+	tapped := make(chan string, 1)
+	pen, config := newPen(adapter, tapped)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+	reconnected := make(chan string, 1)
+	m.SetReconnectHandler(func(deviceName, address string) {
+		reconnected <- address
+	})
+
+	done := play(ctx, "await subscription\ndrop connection\nawait subscription\ntap 3A9A", pen)
+	connect(t, m, config)
+
+	if err := await(t, done, "end of script"); err != nil {
+		t.Fatal(err)
+	}
+	if address := await(t, reconnected, "reconnect"); address != pen.Peripheral().Address.String() {
+		t.Errorf("reconnected to %s, want %s", address, pen.Peripheral().Address.String())
+	}
+	if country := await(t, tapped, "country"); country != "Egypt" {
+		t.Errorf("got %s after the reconnect, want Egypt", country)
+	}
+}
@@ -0,0 +1,145 @@
+// Package simulation provides scriptable virtual Columbus pens and Timeular
+// trackers on top of the in-memory bletest adapter, so that the BLE manager,
+// device packages and reconnect handling can be exercised without hardware.
+//
+// Scripts are plain text, one step per line. A step is a command followed by
+// its arguments and an optional "after <duration>" delay, counted from the
+// previous step:
+//
+//	await subscription
+//	tap globe_hex 3A99 after 2s
+//	flip to side 5 after 500ms
+//	drop connection
+//	wait 1s
+//
+// Blank lines and lines starting with # are ignored.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Target executes the commands of a script, e.g. a ColumbusPen or TimeularTracker
+type Target interface {
+	Execute(ctx context.Context, command string, args []string) error
+}
+
+// Step is a single command of a script
+type Step struct {
+	Line    int
+	Delay   time.Duration
+	Command string
+	Args    []string
+}
+
+// String returns the step in script syntax
+func (s Step) String() string {
+	text := strings.Join(append([]string{s.Command}, s.Args...), " ")
+	if s.Delay > 0 {
+		text += " after " + s.Delay.String()
+	}
+	return text
+}
+
+// Script is a parsed sequence of simulation steps
+type Script []Step
+
+// ParseScript parses the textual script format described in the package documentation
+func ParseScript(src string) (Script, error) {
+	var script Script
+
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		step := Step{Line: i + 1}
+
+		if n := len(fields); n >= 2 && fields[n-2] == "after" {
+			delay, err := time.ParseDuration(fields[n-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid delay %q: %v", i+1, fields[n-1], err)
+			}
+			step.Delay = delay
+			fields = fields[:n-2]
+		}
+
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("line %d: missing command", i+1)
+		}
+
+		step.Command = fields[0]
+		step.Args = fields[1:]
+		script = append(script, step)
+	}
+
+	return script, nil
+}
+
+// MustParseScript is like ParseScript but panics if the script cannot be parsed
+func MustParseScript(src string) Script {
+	script, err := ParseScript(src)
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// Run executes the script against target, honouring delays, until the script
+// ends, a step fails or ctx is cancelled.
+func (s Script) Run(ctx context.Context, target Target) error {
+	for _, step := range s {
+		if err := sleep(ctx, step.Delay); err != nil {
+			return err
+		}
+
+		if step.Command == "wait" {
+			if err := runWait(ctx, step.Args); err != nil {
+				return fmt.Errorf("line %d: %v", step.Line, err)
+			}
+			continue
+		}
+
+		if err := target.Execute(ctx, step.Command, step.Args); err != nil {
+			return fmt.Errorf("line %d (%s): %v", step.Line, step, err)
+		}
+	}
+
+	return nil
+}
+
+// runWait implements the built-in "wait <duration>" command
+func runWait(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: wait <duration>")
+	}
+
+	duration, err := time.ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %v", args[0], err)
+	}
+
+	return sleep(ctx, duration)
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
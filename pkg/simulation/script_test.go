@@ -0,0 +1,99 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript(`
+# The pen taps a country and loses its link
+await subscription
+tap globe_hex 3A99 after 2s
+
+Drop Connection after 500ms
+wait 1s
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Script{
+		{Line: 3, Command: "await", Args: []string{"subscription"}},
+		{Line: 4, Delay: 2 * time.Second, Command: "tap", Args: []string{"globe_hex", "3a99"}},
+		{Line: 6, Delay: 500 * time.Millisecond, Command: "drop", Args: []string{"connection"}},
+		{Line: 7, Command: "wait", Args: []string{"1s"}},
+	}
+	if !reflect.DeepEqual(script, want) {
+		t.Errorf("got %v, want %v", script, want)
+	}
+	if got := script[1].String(); got != "tap globe_hex 3a99 after 2s" {
+		t.Errorf("got step %q, want %q", got, "tap globe_hex 3a99 after 2s")
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"invalid delay", "tap 3A99 after soon", `line 1: invalid delay "soon"`},
+		{"missing command", "await subscription\nafter 1s", "line 2: missing command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := ParseScript(tt.src)
+			if err == nil {
+				t.Fatalf("got script %v, want an error", script)
+			}
+			if !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("got error %q, want it to start with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// recorder is a Target that records the commands it executes
+type recorder struct {
+	steps []string
+	fail  string // command to fail
+}
+
+func (r *recorder) Execute(ctx context.Context, command string, args []string) error {
+	if command == r.fail {
+		return errors.New("failed")
+	}
+	r.steps = append(r.steps, strings.Join(append([]string{command}, args...), " "))
+	return nil
+}
+
+func TestScriptRun(t *testing.T) {
+	target := &recorder{fail: "flip"}
+	script := MustParseScript("send 01\nwait 1ms\nsend 02 after 1ms\nflip 3\nsend 03")
+
+	err := script.Run(context.Background(), target)
+	if err == nil || err.Error() != "line 4 (flip 3): failed" {
+		t.Errorf("got error %v, want the failure of line 4", err)
+	}
+	if want := []string{"send 01", "send 02"}; !reflect.DeepEqual(target.steps, want) {
+		t.Errorf("executed %q, want %q", target.steps, want)
+	}
+}
+
+func TestScriptRunStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	target := &recorder{}
+	if err := MustParseScript("send 01 after 1h").Run(ctx, target); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if len(target.steps) != 0 {
+		t.Errorf("executed %q after cancellation", target.steps)
+	}
+}
@@ -4,8 +4,12 @@ package timeular
 
 import (
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/internal/logging"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
 	"tinygo.org/x/bluetooth"
 )
 
@@ -29,6 +33,11 @@ type SideChangeHandler func(deviceName string, side byte) error
 // DataHandler defines the function signature for handling raw data from the device
 type DataHandler func(deviceName string, data []byte) error
 
+// Reader reads the side characteristic, e.g. a ble.Characteristic or *bluetooth.DeviceCharacteristic
+type Reader interface {
+	Read(data []byte) (int, error)
+}
+
 // Device represents a single Timeular tracker device
 type Device struct {
 	name              string
@@ -36,10 +45,11 @@ type Device struct {
 	lastSide          byte
 	sideChangeHandler SideChangeHandler
 	dataHandler       DataHandler
-	stopChannel       chan bool
-	running           bool
+	stopPolling       chan struct{} // nil while not polling
 	pollInterval      time.Duration
-	characteristic    *bluetooth.DeviceCharacteristic
+	characteristic    Reader
+	logger            *slog.Logger
+	mu                sync.Mutex
 }
 
 // Config holds configuration options for a Timeular device
@@ -52,8 +62,8 @@ type Config struct {
 func NewDevice() *Device {
 	return &Device{
 		name:         DefaultDeviceName,
-		stopChannel:  make(chan bool, 1),
 		pollInterval: DefaultPollInterval,
+		logger:       logging.Discard(),
 	}
 }
 
@@ -104,34 +114,48 @@ func (d *Device) OnSideChange(handler SideChangeHandler) {
 	d.sideChangeHandler = handler
 }
 
-// OnData sets the handler function for raw data (called before side processing)
+// OnData sets the handler function for raw data (called before side processing).
+// While polling, it is only called when the side changed.
 func (d *Device) OnData(handler DataHandler) {
 	d.dataHandler = handler
 }
 
-// SetPollInterval sets the interval for polling the device for side changes
+// SetLogger sets the logger for polling errors (nil discards them, the default)
+func (d *Device) SetLogger(logger *slog.Logger) {
+	d.logger = logging.OrDiscard(logger)
+}
+
+// SetPollInterval sets the interval for polling the device for side changes.
+// It takes effect the next time polling starts.
 func (d *Device) SetPollInterval(interval time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.pollInterval = interval
 }
 
 // GetCurrentSide returns the current side of the tracker
 func (d *Device) GetCurrentSide() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return d.currentSide
 }
 
 // GetLastSide returns the previous side of the tracker
 func (d *Device) GetLastSide() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return d.lastSide
 }
 
 // IsRunning returns whether the device is currently polling
 func (d *Device) IsRunning() bool {
-	return d.running
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopPolling != nil
 }
 
 // ProcessNotification processes incoming BLE notifications from the tracker
 // This is called by the BLE manager when data is received
-// Note: Timeular devices typically use polling instead of notifications
 func (d *Device) ProcessNotification(deviceName string, data []byte) error {
 	// Call data handler if set
 	if d.dataHandler != nil {
@@ -145,11 +169,6 @@ func (d *Device) ProcessNotification(deviceName string, data []byte) error {
 		return d.ProcessSideData(data)
 	}
 
-	// Start polling if not already running (for devices that don't send notifications)
-	if !d.running {
-		go d.startPolling()
-	}
-
 	return nil
 }
 
@@ -166,40 +185,47 @@ func (d *Device) ProcessSideData(data []byte) error {
 	}
 
 	// Update sides
+	d.mu.Lock()
 	d.lastSide = d.currentSide
 	d.currentSide = side
+	changed := d.currentSide != d.lastSide
+	d.mu.Unlock()
 
 	// Call handler if side changed
-	if d.currentSide != d.lastSide && d.sideChangeHandler != nil {
-		return d.sideChangeHandler(d.name, d.currentSide)
+	if changed && d.sideChangeHandler != nil {
+		return d.sideChangeHandler(d.name, side)
 	}
 
 	return nil
 }
 
-// StartPolling manually starts the polling routine (usually not needed)
+// StartPolling starts reading the side characteristic every poll interval.
+// It is a no-op if polling is already running.
 func (d *Device) StartPolling() {
-	if !d.running {
-		go d.startPolling()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopPolling != nil {
+		return
 	}
+	d.stopPolling = make(chan struct{})
+	go d.poll(d.stopPolling, d.pollInterval)
 }
 
-// startPolling starts the polling routine to read the device state
-func (d *Device) startPolling() {
-	d.running = true
-	ticker := time.NewTicker(d.pollInterval)
+// poll reads the device state every interval until stop is closed
+func (d *Device) poll(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-d.stopChannel:
-			d.running = false
+		case <-stop:
 			return
 		case <-ticker.C:
 			// Poll the device for current state
 			if err := d.pollDeviceState(); err != nil {
 				// Log error but continue polling
-				fmt.Printf("⚠️ Polling error for %s: %v\n", d.name, err)
+				d.logger.Warn("polling error", "device", d.name, "phase", "poll", "error", err)
 			}
 		}
 	}
@@ -207,47 +233,103 @@ func (d *Device) startPolling() {
 
 // pollDeviceState reads the current state from the device characteristic
 func (d *Device) pollDeviceState() error {
-	if d.characteristic == nil {
+	d.mu.Lock()
+	characteristic := d.characteristic
+	d.mu.Unlock()
+
+	if characteristic == nil {
 		return fmt.Errorf("characteristic not available")
 	}
 
 	// Read data from characteristic (single byte for side data)
 	data := make([]byte, 1)
-	n, err := d.characteristic.Read(data)
+	n, err := characteristic.Read(data)
 	if err != nil {
 		return fmt.Errorf("failed to read characteristic: %v", err)
 	}
 
 	// Trim data to actual bytes read
-	data = data[:n]
+	data = data[:min(n, len(data))]
+
+	// The tracker reports its side on every read; only a change is a new value
+	d.mu.Lock()
+	unchanged := len(data) == 1 && data[0] == d.currentSide
+	d.mu.Unlock()
+	if unchanged {
+		return nil
+	}
 
-	// Process the data
-	return d.ProcessSideData(data)
+	// Process the data like a notification
+	return d.ProcessNotification(d.name, data)
 }
 
-// SetCharacteristic sets the BLE characteristic for polling (used internally by BLE manager)
-func (d *Device) SetCharacteristic(char *bluetooth.DeviceCharacteristic) {
+// SetCharacteristic sets the side characteristic and starts polling it, or
+// stops polling if char is nil. It matches the Reader of a ble.ModePoll
+// subscription, which hands over the characteristic on every (re)connect and
+// withdraws it on disconnect.
+func (d *Device) SetCharacteristic(char Reader) {
+	d.mu.Lock()
 	d.characteristic = char
+	d.mu.Unlock()
+
+	if char == nil {
+		d.Stop()
+		return
+	}
+	d.StartPolling()
 }
 
 // Stop stops the polling routine
 func (d *Device) Stop() {
-	if d.running {
-		select {
-		case d.stopChannel <- true:
-		default:
-		}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopPolling != nil {
+		close(d.stopPolling)
+		d.stopPolling = nil
 	}
 }
 
 // Reset resets the device state
 func (d *Device) Reset() {
 	d.Stop()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.currentSide = 0
 	d.lastSide = 0
 	d.characteristic = nil
 }
 
+// Device is connected with ble.Manager.Register, which polls the side
+// characteristic while connected and resets the device on disconnect
+var (
+	_ ble.Peripheral           = (*Device)(nil)
+	_ ble.PeripheralConfigurer = (*Device)(nil)
+	_ ble.ConnectedHook        = (*Device)(nil)
+	_ ble.DisconnectedHook     = (*Device)(nil)
+)
+
+// ConfigureDevice implements ble.PeripheralConfigurer. The side
+// characteristic is polled, so the tracker does not need to send notifications.
+func (d *Device) ConfigureDevice(config *ble.DeviceConfig) {
+	for i := range config.Subscriptions {
+		config.Subscriptions[i].Mode = ble.ModePoll
+	}
+}
+
+// OnConnected implements ble.ConnectedHook and starts polling the side characteristic
+func (d *Device) OnConnected(deviceName string, characteristic ble.Characteristic) {
+	if characteristic != nil {
+		d.SetCharacteristic(characteristic)
+	}
+}
+
+// OnDisconnected implements ble.DisconnectedHook and resets the device state
+func (d *Device) OnDisconnected(deviceName string, err error) {
+	d.Reset()
+}
+
 // ResolveSide resolves the current side from Timeular device data
 func ResolveSide(data []byte) (byte, error) {
 	// For the single-byte side characteristic, the data IS the side
@@ -0,0 +1,132 @@
+package timeular_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/ble/bletest"
+	"github.com/coded-aesthetics/bartolome-ble-toolkit/pkg/timeular"
+)
+
+// testTimeout bounds every wait for the manager
+const testTimeout = 2 * time.Second
+
+// newTestTracker adds an advertising tracker lying on side to adapter
+func newTestTracker(adapter *bletest.Adapter, name string, side byte) (*bletest.Peripheral, *bletest.Characteristic) {
+	peripheral := bletest.NewPeripheral(name, bletest.MustParseAddress("11:22:33:44:55:66"))
+	characteristic := peripheral.AddService(timeular.ServiceUUID).AddCharacteristic(timeular.CharacteristicUUID)
+	characteristic.SetValue([]byte{side})
+	adapter.AddPeripheral(peripheral)
+	return peripheral, characteristic
+}
+
+// register connects device and fails the test if it cannot
+func register(t *testing.T, m *ble.SimpleManager, device *timeular.Device) {
+	t.Helper()
+	for _, result := range m.Register(device) {
+		if result.Err != nil {
+			t.Fatalf("failed to register %s: %v", result.Name, result.Err)
+		}
+	}
+}
+
+// await fails the test unless condition holds within testTimeout
+func await(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s within %s", what, testTimeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegisterResetsOnDisconnect(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	newTestTracker(adapter, "Tracker", 3)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	device := timeular.NewDeviceWithConfig(timeular.Config{Name: "Tracker", PollInterval: 5 * time.Millisecond})
+	register(t, m, device)
+
+	// OnConnected hands over the characteristic, which is then polled
+	await(t, "the side was not polled", func() bool { return device.GetCurrentSide() == 3 })
+	if !device.IsRunning() {
+		t.Error("the tracker is not polling while connected")
+	}
+
+	if err := m.Disconnect("Tracker"); err != nil {
+		t.Fatal(err)
+	}
+	await(t, "the tracker was not reset", func() bool {
+		return !device.IsRunning() && device.GetCurrentSide() == 0
+	})
+	if side := device.GetLastSide(); side != 0 {
+		t.Errorf("got last side %d after the reset, want 0", side)
+	}
+}
+
+func TestPollReportsChangesOnly(t *testing.T) {
+	adapter := bletest.NewAdapter()
+	_, characteristic := newTestTracker(adapter, "Tracker", 3)
+
+	m := ble.NewSimpleManagerWithAdapter(adapter)
+	defer m.Close()
+
+	const interval = 5 * time.Millisecond
+	device := timeular.NewDeviceWithConfig(timeular.Config{Name: "Tracker", PollInterval: interval})
+	data := make(chan []byte, 16)
+	device.OnData(func(deviceName string, value []byte) error {
+		data <- value
+		return nil
+	})
+	sides := make(chan byte, 16)
+	device.OnSideChange(func(deviceName string, side byte) error {
+		sides <- side
+		return nil
+	})
+
+	// The manager hands the characteristic of the ModePoll subscription to the device
+	config := ble.DeviceConfig{
+		Name: "Tracker",
+		Subscriptions: []ble.Subscription{{
+			ServiceUUID:        timeular.ServiceUUID,
+			CharacteristicUUID: timeular.CharacteristicUUID,
+			Mode:               ble.ModePoll,
+			Reader: func(_ string, characteristic ble.Characteristic) {
+				device.SetCharacteristic(characteristic)
+			},
+		}},
+	}
+	for _, result := range m.ConnectDevices([]ble.DeviceConfig{config}) {
+		if result.Err != nil {
+			t.Fatalf("failed to connect %s: %v", result.Name, result.Err)
+		}
+	}
+
+	for _, side := range []byte{3, 5} {
+		characteristic.SetValue([]byte{side})
+		select {
+		case got := <-sides:
+			if got != side {
+				t.Fatalf("got side %d, want %d", got, side)
+			}
+		case <-time.After(testTimeout):
+			t.Fatalf("side %d was not reported within %s", side, testTimeout)
+		}
+
+		// Further polls of the same side are not reported
+		time.Sleep(10 * interval)
+		if n := len(data); n != 1 {
+			t.Errorf("OnData was called %d times for side %d, want once", n, side)
+		}
+		if n := len(sides); n != 0 {
+			t.Errorf("OnSideChange was called %d more times for side %d", n, side)
+		}
+		<-data
+	}
+}
@@ -83,24 +83,11 @@ func ExampleBLEConfiguration() []ble.DeviceConfig {
 	tracker1 := NewDeviceWithName("Office Tracker")
 	tracker2 := NewDeviceWithName("Home Tracker")
 
-	// Configure both devices
+	// Derive the configs of both devices, which poll the side characteristic
+	// while connected and reset on disconnect
 	configs := []ble.DeviceConfig{
-		{
-			Name:               tracker1.GetName(),
-			ServiceUUID:        tracker1.GetServiceUUID(),
-			CharacteristicUUID: tracker1.GetCharacteristicUUID(),
-			NotificationHandler: func(deviceName string, data []byte) error {
-				return tracker1.ProcessNotification(deviceName, data)
-			},
-		},
-		{
-			Name:               tracker2.GetName(),
-			ServiceUUID:        tracker2.GetServiceUUID(),
-			CharacteristicUUID: tracker2.GetCharacteristicUUID(),
-			NotificationHandler: func(deviceName string, data []byte) error {
-				return tracker2.ProcessNotification(deviceName, data)
-			},
-		},
+		ble.PeripheralConfig(tracker1),
+		ble.PeripheralConfig(tracker2),
 	}
 
 	return configs